@@ -0,0 +1,97 @@
+package gograce
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Clock is the source of time of Graceful, the SignalHandler and TimeoutHandler.
+// It can be replaced in tests to control time, see the gogracetest package.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc calls f on its own go-routine once d has elapsed, like time.AfterFunc.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer created by Clock.AfterFunc. *time.Timer implements Timer.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SignalSource delivers signals to the SignalHandler. It can be replaced in tests
+// to send signals without signaling the process, see the gogracetest package.
+type SignalSource interface {
+	// Notify relays the signals sig to c, like signal.Notify.
+	Notify(c chan<- os.Signal, sig ...os.Signal)
+
+	// Stop stops relaying signals to c, like signal.Stop.
+	Stop(c chan<- os.Signal)
+}
+
+// realClock is the default Clock which uses the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// withTimeout is like context.WithTimeout but measures timeout with clock.
+func withTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	var (
+		cancelCtx, cancel = context.WithCancelCause(ctx)
+		timer             = clock.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	)
+
+	c := &clockContext{Context: cancelCtx, deadline: clock.Now().Add(timeout)}
+	return c, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// clockContext is the context returned by withTimeout for clocks other than realClock.
+type clockContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) {
+	if deadline, ok := c.Context.Deadline(); ok && deadline.Before(c.deadline) {
+		return deadline, true
+	}
+
+	return c.deadline, true
+}
+
+func (c *clockContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+// osSignals is the default SignalSource which uses the os/signal package.
+type osSignals struct{}
+
+func (osSignals) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	signal.Notify(c, sig...)
+}
+
+func (osSignals) Stop(c chan<- os.Signal) {
+	signal.Stop(c)
+}
@@ -0,0 +1,256 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrDependencyCycle is returned by Wait when the dependencies of the registered
+	// components form a cycle.
+	ErrDependencyCycle = errors.New("dependency cycle")
+
+	// ErrUnknownDependency is returned by Wait when a component depends on a
+	// component that has not been registered.
+	ErrUnknownDependency = errors.New("unknown dependency")
+
+	// ErrDuplicateComponent is returned by Wait when two components are registered
+	// with the same name.
+	ErrDuplicateComponent = errors.New("duplicate component")
+)
+
+// A Component is a part of the application which has to be started and stopped
+// in a specific order.
+type Component interface {
+	// Start is called with the run context and should return once the component is
+	// started. Long-running work should be started in another go-routine, e.g. with
+	// Graceful.GoWithContext.
+	Start(ctx context.Context) error
+
+	// Stop is called with the shutdown context once shutdown begins, only if
+	// Start was called and has returned without an error.
+	Stop(ctx context.Context) error
+}
+
+type component struct {
+	name      string
+	dependsOn []string
+	Component
+
+	startTask *task
+	stopTask  *task
+}
+
+// Register adds c to the components managed by grace. Components are started
+// when Wait is called, after all of their dependencies have been started, and are
+// stopped in reverse order once the context of grace is canceled. If c implements
+// Reloader it is registered with RegisterReloader as well.
+func (grace *Graceful) Register(name string, c Component, dependsOn ...string) {
+	comp := &component{
+		name:      name,
+		dependsOn: dependsOn,
+		Component: c,
+		startTask: grace.newTask(name + ".Start"),
+		stopTask:  grace.newTask(name + ".Stop"),
+	}
+
+	grace.mu.Lock()
+	grace.components = append(grace.components, comp)
+	grace.mu.Unlock()
+
+	if r, ok := c.(Reloader); ok {
+		grace.RegisterReloader(name, r)
+	}
+}
+
+// PendingComponents returns the names of components that have been started but
+// have not stopped yet, in the order they will be stopped. During shutdown the first
+// one is the component that is blocking it.
+func (grace *Graceful) PendingComponents() []string {
+	grace.mu.Lock()
+	defer grace.mu.Unlock()
+
+	names := make([]string, 0, len(grace.started))
+	for i := len(grace.started) - 1; i >= 0; i-- {
+		names = append(names, grace.started[i].name)
+	}
+
+	return names
+}
+
+// startComponents sorts the registered components and starts the go-routines that
+// start and stop them. If the components cannot be sorted the error is passed to
+// the errgroup.Group so Wait returns it.
+func (grace *Graceful) startComponents() {
+	grace.mu.Lock()
+	components := grace.components
+	grace.mu.Unlock()
+
+	if len(components) == 0 {
+		grace.ready()
+		return
+	}
+
+	order, err := sortComponents(components)
+	if err != nil {
+		grace.g.Go(func() error {
+			return err
+		})
+		return
+	}
+
+	started := make(chan struct{})
+	grace.g.Go(func() error {
+		defer close(started)
+		return grace.runComponents(order)
+	})
+
+	grace.g.Go(func() error {
+		<-grace.ctx.Done()
+		grace.startShutdown()
+		<-started
+		return grace.stopComponents()
+	})
+}
+
+// runComponents starts components in order, until one of them fails or
+// shutdown begins.
+func (grace *Graceful) runComponents(order []*component) error {
+	for _, c := range order {
+		if grace.ctx.Err() != nil {
+			return nil
+		}
+
+		grace.logger.Info("starting component", "component", c.name)
+		err := grace.runTask(c.startTask, func() error {
+			return c.Start(grace.ctx)
+		})
+		if err != nil {
+			return fmt.Errorf("component '%s': start: %w", c.name, err)
+		}
+
+		grace.mu.Lock()
+		grace.started = append(grace.started, c)
+		grace.mu.Unlock()
+	}
+
+	grace.ready()
+	return nil
+}
+
+// stopComponents stops started components in reverse order. All of them are
+// stopped even if some fail.
+func (grace *Graceful) stopComponents() error {
+	var (
+		ctx  = grace.shutdownCtx
+		errs []error
+	)
+
+	for {
+		grace.mu.Lock()
+		if len(grace.started) == 0 {
+			grace.mu.Unlock()
+			break
+		}
+		c := grace.started[len(grace.started)-1]
+		grace.mu.Unlock()
+
+		grace.logger.Info("stopping component", "component", c.name)
+		err := grace.runTask(c.stopTask, func() error {
+			return c.Stop(ctx)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("component '%s': stop: %w", c.name, err))
+		}
+
+		grace.mu.Lock()
+		grace.started = grace.started[:len(grace.started)-1]
+		grace.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// ready is called once all components have been started.
+func (grace *Graceful) ready() {
+	grace.setState(StateRunning)
+	grace.notifyParent()
+
+	// MAINPID makes the new process the main process after a restart
+	grace.sdNotify("READY=1\nMAINPID=" + strconv.Itoa(os.Getpid()))
+}
+
+// sortComponents returns components sorted so every component comes after its
+// dependencies. Components without an order between them keep the order they
+// were registered in.
+func sortComponents(components []*component) ([]*component, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var (
+		byName = make(map[string]*component, len(components))
+		state  = make(map[string]int, len(components))
+		order  = make([]*component, 0, len(components))
+		path   []string
+		visit  func(c *component) error
+	)
+
+	for _, c := range components {
+		if _, ok := byName[c.name]; ok {
+			return nil, fmt.Errorf("%w: '%s'", ErrDuplicateComponent, c.name)
+		}
+		byName[c.name] = c
+	}
+
+	visit = func(c *component) error {
+		switch state[c.name] {
+		case visited:
+			return nil
+		case visiting:
+			// only report the part of the path that forms the cycle
+			cycle := path
+			for i, name := range path {
+				if name == c.name {
+					cycle = path[i:]
+					break
+				}
+			}
+
+			return fmt.Errorf("%w: %s -> %s", ErrDependencyCycle, strings.Join(cycle, " -> "), c.name)
+		}
+
+		state[c.name] = visiting
+		path = append(path, c.name)
+
+		for _, name := range c.dependsOn {
+			dep, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("%w: '%s' depends on '%s'", ErrUnknownDependency, c.name, name)
+			}
+
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[c.name] = visited
+		order = append(order, c)
+		return nil
+	}
+
+	for _, c := range components {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,118 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testComponent struct {
+	name     string
+	mu       *sync.Mutex
+	events   *[]string
+	startErr error
+	stopErr  error
+}
+
+func (c *testComponent) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, "start "+c.name)
+	return c.startErr
+}
+
+func (c *testComponent) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, "stop "+c.name)
+	if c.stopErr != nil {
+		return c.stopErr
+	}
+
+	return ctx.Err()
+}
+
+func TestComponents(t *testing.T) {
+	var (
+		mu               sync.Mutex
+		events           []string
+		newTestComponent = func(name string) *testComponent {
+			return &testComponent{name: name, mu: &mu, events: &events}
+		}
+	)
+
+	// cancel the parent context once done so signal handlers do not outlive the tests
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("start and stop in order", func(t *testing.T) {
+		events = nil
+		grace := NewGracefulWithContext(ctx, Options{})
+
+		grace.Register("http", newTestComponent("http"), "cache", "db")
+		grace.Register("cache", newTestComponent("cache"), "db")
+		grace.Register("db", newTestComponent("db"))
+		grace.Register("metrics", newTestComponent("metrics"))
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- grace.Wait()
+		}()
+
+		require.Eventually(t, func() bool {
+			return len(grace.PendingComponents()) == 4
+		}, time.Second, time.Millisecond)
+		require.Equal(t, []string{"metrics", "http", "cache", "db"}, grace.PendingComponents())
+
+		grace.sh.sigChan <- syscall.SIGINT
+
+		require.NoError(t, <-errCh)
+		require.Empty(t, grace.PendingComponents())
+		require.Equal(t, []string{
+			"start db", "start cache", "start http", "start metrics",
+			"stop metrics", "stop http", "stop cache", "stop db",
+		}, events)
+	})
+
+	t.Run("failed start stops started components", func(t *testing.T) {
+		events = nil
+		var (
+			grace   = NewGracefulWithContext(ctx, Options{})
+			errHTTP = errors.New("address already in use")
+			http    = newTestComponent("http")
+		)
+
+		http.startErr = errHTTP
+		grace.Register("db", newTestComponent("db"))
+		grace.Register("http", http, "db")
+		grace.Register("metrics", newTestComponent("metrics"))
+
+		require.ErrorIs(t, grace.Wait(), errHTTP)
+		require.Equal(t, []string{"start db", "start http", "stop db"}, events)
+	})
+
+	t.Run("dependency errors", func(t *testing.T) {
+		grace := NewGracefulWithContext(ctx, Options{})
+		grace.Register("a", newTestComponent("a"), "b")
+		grace.Register("b", newTestComponent("b"), "c")
+		grace.Register("c", newTestComponent("c"), "b")
+
+		err := grace.Wait()
+		require.ErrorIs(t, err, ErrDependencyCycle)
+		require.EqualError(t, err, "dependency cycle: b -> c -> b")
+
+		grace = NewGracefulWithContext(ctx, Options{})
+		grace.Register("a", newTestComponent("a"), "b")
+		require.ErrorIs(t, grace.Wait(), ErrUnknownDependency)
+
+		grace = NewGracefulWithContext(ctx, Options{})
+		grace.Register("a", newTestComponent("a"))
+		grace.Register("a", newTestComponent("a"))
+		require.ErrorIs(t, grace.Wait(), ErrDuplicateComponent)
+	})
+}
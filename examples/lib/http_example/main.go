@@ -2,10 +2,8 @@ package main
 
 import (
 	"context"
-	"errors"
 	"github.com/itzloop/gograce"
 	"log"
-	"net"
 	"net/http"
 	"time"
 )
@@ -22,9 +20,10 @@ func main() {
 	// create a simple http server
 	exampleHTTPServer := NewExampleHTTPServer(":8000")
 
-	// add start and close operations to grace instance
-	grace.GoWithContext(exampleHTTPServer.start)
-	grace.GoWithContext(exampleHTTPServer.close)
+	// serve the http server and shut it down in the drain phase, so
+	// backup only runs once all requests are done
+	grace.HTTPServer(exampleHTTPServer.httpServer, gograce.HTTPServerOptions{Phase: "drain"})
+	grace.Phase("flush", gograce.PhaseOptions{}).Hook(exampleHTTPServer.backup)
 
 	// wait for all go-routines or the cancel signal and
 	// if any error is encountered, call log.Fatal
@@ -65,39 +64,10 @@ func NewExampleHTTPServer(addr string) *ExampleHTTPServer {
 	}
 }
 
-// start starts the httpServer and sets the http.Server.BaseContext.
-func (s *ExampleHTTPServer) start(ctx context.Context) (err error) {
-	s.httpServer.BaseContext = func(_ net.Listener) context.Context {
-		return ctx
-	}
-
-	err = s.httpServer.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		return err
-	}
-
-	return nil
-}
-
 // backup runs an imaginary backup routine
-func (s *ExampleHTTPServer) backup() (err error) {
+func (s *ExampleHTTPServer) backup(ctx context.Context) (err error) {
 	log.Printf("ExampleHTTPServer.backup: backing up some imaginary stuff")
 	time.Sleep(time.Second * 2)
 	log.Printf("ExampleHTTPServer.backup: backuped everything")
 	return nil
 }
-
-// shutdown shuts down the http server so no new connectionos
-// are accepted and running connections will have time to finish
-func (s *ExampleHTTPServer) shutdown() (err error) {
-	log.Printf("ExampleHTTPServer.shutdown: shutting down http server")
-	return s.httpServer.Shutdown(context.Background())
-}
-
-// close runs shutdown and backup and errors.Join their errors and returns
-func (s *ExampleHTTPServer) close(ctx context.Context) (err error) {
-	<-ctx.Done()
-	err = errors.Join(s.shutdown())
-	err = errors.Join(s.backup())
-	return
-}
@@ -0,0 +1,100 @@
+package gograce
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var (
+	// ErrForced is returned by Wait when the application is forcefully quit, e.g. by a
+	// second signal. Wait returns it right away, without waiting for go-routines and hooks.
+	ErrForced = errors.New("forcefully quit")
+
+	// ErrTimeout is returned by Wait when Options.Timeout is reached. Wait returns it
+	// right away, without waiting for go-routines and hooks.
+	ErrTimeout = errors.New("shutdown timeout reached")
+)
+
+// ExitCodes maps how Run ended to the exit code it returns.
+type ExitCodes struct {
+	// Error is returned when Wait returns an error other than ErrForced and ErrTimeout.
+	// a zero-value indicates 1.
+	Error int
+
+	// Timeout is returned when Options.Timeout is reached.
+	// a zero-value indicates 1.
+	Timeout int
+
+	// Forced maps the signal that forcefully quit the application to the exit code.
+	// Signals that are not in Forced return 128 plus the signal number, like shells
+	// do for processes killed by a signal, e.g. 130 for SIGINT and 143 for SIGTERM.
+	Forced map[os.Signal]int
+}
+
+// Run calls Wait and returns the exit code for how it ended, see Options.ExitCodes.
+// It is meant to be the only place deciding how the application exits, so deferred
+// functions in main run before the process exits:
+//
+//	func main() {
+//		os.Exit(run())
+//	}
+//
+//	func run() int {
+//		grace := gograce.NewGraceful(gograce.Options{})
+//		defer cleanup()
+//		return grace.Run()
+//	}
+func (grace *Graceful) Run() int {
+	err := grace.Wait()
+	code := grace.exitCode(err)
+	if err != nil {
+		grace.logger.Error("graceful wait failed", "error", err, "exit_code", code)
+	}
+
+	return code
+}
+
+// exitCode returns the exit code for err returned by Wait.
+func (grace *Graceful) exitCode(err error) int {
+	codes := grace.opts.ExitCodes
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrForced):
+		grace.mu.Lock()
+		sig := grace.forcedBy
+		grace.mu.Unlock()
+
+		if code, ok := codes.Forced[sig]; ok {
+			return code
+		}
+
+		if s, ok := sig.(syscall.Signal); ok {
+			return 128 + int(s)
+		}
+
+		return 1
+	case errors.Is(err, ErrTimeout):
+		if codes.Timeout != 0 {
+			return codes.Timeout
+		}
+
+		return 1
+	default:
+		if codes.Error != 0 {
+			return codes.Error
+		}
+
+		return 1
+	}
+}
+
+// quit makes Wait return err right away. Only the first call has an effect.
+func (grace *Graceful) quit(err error) {
+	grace.quitOnce.Do(func() {
+		grace.quitErr = err
+		close(grace.quitCh)
+	})
+}
@@ -0,0 +1,107 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	// block starts a go-routine on grace which only returns once the test is done.
+	block := func(t *testing.T, grace *Graceful) {
+		done := make(chan struct{})
+		t.Cleanup(func() { close(done) })
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-done
+			return nil
+		})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		grace := NewGracefulWithContext(ctx, Options{Logger: NopLogger()})
+		cancel()
+
+		require.Equal(t, 0, grace.Run())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		grace.Go(func() error { return errors.New("failed") })
+		require.Equal(t, 1, grace.Run())
+
+		grace = NewGracefulWithContext(context.Background(), Options{
+			Logger:    NopLogger(),
+			ExitCodes: ExitCodes{Error: 2},
+		})
+		grace.Go(func() error { return errors.New("failed") })
+		require.Equal(t, 2, grace.Run())
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		forced := make(chan struct{})
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:    NopLogger(),
+			ForceFunc: func() { close(forced) },
+		})
+		block(t, grace)
+
+		grace.sh.sigChan <- syscall.SIGINT
+		grace.sh.sigChan <- syscall.SIGINT
+
+		// Wait returns although the go-routine is still running
+		require.ErrorIs(t, grace.Wait(), ErrForced)
+		require.Equal(t, 130, grace.Run())
+		<-forced
+
+		// the go-routine waiting for the blocked one is shared by all calls
+		before := runtime.NumGoroutine()
+		for i := 0; i < 100; i++ {
+			require.ErrorIs(t, grace.Wait(), ErrForced)
+		}
+
+		require.Less(t, runtime.NumGoroutine(), before+10)
+	})
+
+	t.Run("forced mapping", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:    NopLogger(),
+			ExitCodes: ExitCodes{Forced: map[os.Signal]int{syscall.SIGTERM: 0}},
+		})
+		block(t, grace)
+
+		grace.sh.sigChan <- syscall.SIGINT
+		grace.sh.sigChan <- syscall.SIGTERM
+
+		require.Equal(t, 0, grace.Run())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:  NopLogger(),
+			Timeout: 10 * time.Millisecond,
+		})
+		block(t, grace)
+
+		grace.sh.sigChan <- syscall.SIGTERM
+		require.ErrorIs(t, grace.Wait(), ErrTimeout)
+		require.Equal(t, 1, grace.Run())
+
+		grace = NewGracefulWithContext(context.Background(), Options{
+			Logger:    NopLogger(),
+			Timeout:   10 * time.Millisecond,
+			ExitCodes: ExitCodes{Timeout: 124},
+		})
+		block(t, grace)
+
+		grace.sh.sigChan <- syscall.SIGTERM
+		require.Equal(t, 124, grace.Run())
+	})
+}
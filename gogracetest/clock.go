@@ -0,0 +1,126 @@
+package gogracetest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/itzloop/gograce"
+)
+
+// Clock is a gograce.Clock which only moves when Advance is called.
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*timer
+}
+
+var _ gograce.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the time of the Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// AfterFunc calls f once the Clock has been advanced by d. Unlike time.AfterFunc,
+// f is called on the go-routine calling Advance.
+func (c *Clock) AfterFunc(d time.Duration, f func()) gograce.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{c: c, f: f}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance moves the Clock forward by d and calls the functions of the timers
+// that expire on the way, in the order they expire.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+
+	for len(c.timers) > 0 && !c.timers[0].when.After(end) {
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.when
+
+		// the function might use the Clock
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+
+	c.now = end
+	c.mu.Unlock()
+}
+
+// BlockUntil blocks until at least n timers are waiting to expire. It is used to
+// make sure a timer has been created on another go-routine before calling Advance.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// schedule adds t to the timers, which are sorted by the time they expire.
+// c.mu must be held.
+func (c *Clock) schedule(t *timer, d time.Duration) {
+	t.when = c.now.Add(d)
+	i := sort.Search(len(c.timers), func(i int) bool {
+		return c.timers[i].when.After(t.when)
+	})
+
+	c.timers = append(c.timers, nil)
+	copy(c.timers[i+1:], c.timers[i:])
+	c.timers[i] = t
+	c.cond.Broadcast()
+}
+
+// unschedule removes t from the timers and reports whether it was waiting to expire.
+// c.mu must be held.
+func (c *Clock) unschedule(t *timer) bool {
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+type timer struct {
+	c    *Clock
+	f    func()
+	when time.Time
+}
+
+func (t *timer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	return t.c.unschedule(t)
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	active := t.c.unschedule(t)
+	t.c.schedule(t, d)
+	return active
+}
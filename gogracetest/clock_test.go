@@ -0,0 +1,80 @@
+package gogracetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	t.Run("advance", func(t *testing.T) {
+		var (
+			start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			c     = NewClock(start)
+			fired []time.Duration
+		)
+
+		record := func(d time.Duration) func() {
+			return func() {
+				// timers are called with the time they expire at
+				require.Equal(t, start.Add(d), c.Now())
+				fired = append(fired, d)
+			}
+		}
+
+		c.AfterFunc(3*time.Second, record(3*time.Second))
+		c.AfterFunc(time.Second, record(time.Second))
+		c.AfterFunc(2*time.Second, record(2*time.Second))
+
+		c.Advance(1500 * time.Millisecond)
+		require.Equal(t, []time.Duration{time.Second}, fired)
+		require.Equal(t, start.Add(1500*time.Millisecond), c.Now())
+
+		c.Advance(time.Hour)
+		require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, fired)
+		require.Equal(t, start.Add(time.Hour+1500*time.Millisecond), c.Now())
+	})
+
+	t.Run("stop and reset", func(t *testing.T) {
+		var (
+			c     = NewClock(time.Now())
+			fired int
+			timer = c.AfterFunc(time.Second, func() { fired++ })
+		)
+
+		require.True(t, timer.Stop())
+		require.False(t, timer.Stop())
+		c.Advance(time.Second)
+		require.Zero(t, fired)
+
+		require.False(t, timer.Reset(time.Second))
+		require.True(t, timer.Reset(2*time.Second))
+		c.Advance(time.Second)
+		require.Zero(t, fired)
+
+		c.Advance(time.Second)
+		require.Equal(t, 1, fired)
+		require.False(t, timer.Stop())
+	})
+
+	t.Run("block until", func(t *testing.T) {
+		c := NewClock(time.Now())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.BlockUntil(2)
+		}()
+
+		c.AfterFunc(time.Second, func() {})
+		select {
+		case <-done:
+			t.Fatal("returned with one timer")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		c.AfterFunc(time.Second, func() {})
+		<-done
+	})
+}
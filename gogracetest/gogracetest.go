@@ -0,0 +1,80 @@
+// Package gogracetest helps testing applications using gograce deterministically.
+// Signals are sent with a fake signal source instead of signaling the test binary,
+// time only moves when the fake clock is advanced and quitting forcefully or
+// reaching the timeout is recorded so tests can wait for it.
+//
+//	h := gogracetest.New()
+//	grace := gograce.NewGraceful(h.Options(gograce.Options{Timeout: 15 * time.Second}))
+//	// start go-routines and hooks
+//
+//	h.Signals.Send(syscall.SIGTERM)
+//	h.Clock.BlockUntil(1) // the timeout is armed
+//	h.Clock.Advance(14 * time.Second)
+//	h.Signals.Send(syscall.SIGINT)
+//	<-h.Forced()
+package gogracetest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/itzloop/gograce"
+)
+
+// Harness provides the fake Clock and Signals for a Graceful and records when
+// it quits forcefully or reaches its timeout.
+type Harness struct {
+	Clock   *Clock
+	Signals *Signals
+
+	forced      chan struct{}
+	forceOnce   sync.Once
+	timedOut    chan struct{}
+	timeoutOnce sync.Once
+}
+
+// New returns a Harness with a Clock set to the current time.
+func New() *Harness {
+	return &Harness{
+		Clock:    NewClock(time.Now()),
+		Signals:  NewSignals(),
+		forced:   make(chan struct{}),
+		timedOut: make(chan struct{}),
+	}
+}
+
+// Options returns opts with the Clock, Signals, ForceFunc and TimeoutFunc of h.
+// Logger defaults to gograce.NopLogger and notifying systemd is disabled.
+func (h *Harness) Options(opts gograce.Options) gograce.Options {
+	opts.Clock = h.Clock
+	opts.SignalSource = h.Signals
+	opts.ForceFunc = h.ForceFunc
+	opts.TimeoutFunc = h.TimeoutFunc
+	opts.SystemdNotify = false
+
+	if opts.Logger == nil {
+		opts.Logger = gograce.NopLogger()
+	}
+
+	return opts
+}
+
+// ForceFunc records that the application quit forcefully.
+func (h *Harness) ForceFunc() {
+	h.forceOnce.Do(func() { close(h.forced) })
+}
+
+// TimeoutFunc records that the timeout was reached.
+func (h *Harness) TimeoutFunc() {
+	h.timeoutOnce.Do(func() { close(h.timedOut) })
+}
+
+// Forced returns a channel that is closed once the application quit forcefully.
+func (h *Harness) Forced() <-chan struct{} {
+	return h.forced
+}
+
+// TimedOut returns a channel that is closed once the timeout was reached.
+func (h *Harness) TimedOut() <-chan struct{} {
+	return h.timedOut
+}
@@ -0,0 +1,166 @@
+package gogracetest
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/itzloop/gograce"
+	"github.com/stretchr/testify/require"
+)
+
+// start runs a go-routine on grace which waits for the shutdown context to be done
+// and returns a channel which is closed once graceful shutdown has started.
+func start(grace *gograce.Graceful) <-chan struct{} {
+	draining := make(chan struct{})
+	grace.GoWithContext(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(draining)
+		<-grace.ShutdownContext().Done()
+		return nil
+	})
+
+	return draining
+}
+
+func TestHarness(t *testing.T) {
+	t.Run("second signal", func(t *testing.T) {
+		var (
+			h        = New()
+			grace    = gograce.NewGraceful(h.Options(gograce.Options{Timeout: 15 * time.Second}))
+			draining = start(grace)
+		)
+
+		require.True(t, h.Signals.Send(syscall.SIGTERM))
+		<-draining
+
+		h.Clock.BlockUntil(1)
+		h.Clock.Advance(14 * time.Second)
+
+		deadline, ok := grace.ShutdownContext().Deadline()
+		require.True(t, ok)
+		require.Equal(t, time.Second, deadline.Sub(h.Clock.Now()))
+
+		require.True(t, h.Signals.Send(syscall.SIGINT))
+		<-h.Forced()
+		require.Equal(t, 130, grace.Run())
+
+		select {
+		case <-h.TimedOut():
+			t.Fatal("timed out before the timeout was reached")
+		default:
+		}
+
+		h.Clock.Advance(time.Second)
+		<-h.TimedOut()
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var (
+			h        = New()
+			grace    = gograce.NewGraceful(h.Options(gograce.Options{Timeout: 15 * time.Second}))
+			draining = start(grace)
+		)
+
+		require.True(t, h.Signals.Send(syscall.SIGTERM))
+		<-draining
+
+		h.Clock.BlockUntil(1)
+		h.Clock.Advance(15*time.Second - time.Nanosecond)
+		require.NoError(t, grace.ShutdownContext().Err())
+
+		h.Clock.Advance(time.Nanosecond)
+		<-h.TimedOut()
+		require.ErrorIs(t, grace.ShutdownContext().Err(), context.DeadlineExceeded)
+		require.ErrorIs(t, grace.Wait(), gograce.ErrTimeout)
+
+		select {
+		case <-h.Forced():
+			t.Fatal("quit forcefully without a second signal")
+		default:
+		}
+	})
+
+	t.Run("extend", func(t *testing.T) {
+		var (
+			h        = New()
+			grace    = gograce.NewGraceful(h.Options(gograce.Options{Timeout: 15 * time.Second}))
+			draining = start(grace)
+		)
+
+		require.True(t, h.Signals.Send(syscall.SIGTERM))
+		<-draining
+
+		h.Clock.BlockUntil(1)
+		h.Clock.Advance(10 * time.Second)
+		grace.ExtendTimeout(20 * time.Second)
+
+		remaining, ok := grace.RemainingTimeout()
+		require.True(t, ok)
+		require.Equal(t, 25*time.Second, remaining)
+
+		h.Clock.Advance(24 * time.Second)
+		require.NoError(t, grace.ShutdownContext().Err())
+
+		h.Clock.Advance(time.Second)
+		<-h.TimedOut()
+		require.ErrorIs(t, grace.Wait(), gograce.ErrTimeout)
+	})
+
+	t.Run("phase budget", func(t *testing.T) {
+		var (
+			h        = New()
+			grace    = gograce.NewGraceful(h.Options(gograce.Options{Timeout: 15 * time.Second}))
+			drainCtx = make(chan context.Context, 1)
+		)
+
+		grace.Phase("drain", gograce.PhaseOptions{}).Hook(func(ctx context.Context) error {
+			drainCtx <- ctx
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		grace.Phase("close", gograce.PhaseOptions{Timeout: 5 * time.Second}).Hook(func(ctx context.Context) error {
+			return nil
+		})
+
+		require.True(t, h.Signals.Send(syscall.SIGTERM))
+		ctx := <-drainCtx
+
+		// the timeout and the budget of drain
+		h.Clock.BlockUntil(2)
+
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.Equal(t, 10*time.Second, deadline.Sub(h.Clock.Now()))
+
+		h.Clock.Advance(10*time.Second - time.Nanosecond)
+		require.NoError(t, ctx.Err())
+
+		h.Clock.Advance(time.Nanosecond)
+		err := grace.Wait()
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "phase 'drain'")
+
+		select {
+		case <-h.TimedOut():
+			t.Fatal("timed out before the timeout was reached")
+		default:
+		}
+	})
+
+	t.Run("not listened to", func(t *testing.T) {
+		h := New()
+		grace := gograce.NewGraceful(h.Options(gograce.Options{Signals: []os.Signal{syscall.SIGTERM}}))
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		require.False(t, h.Signals.Send(syscall.SIGINT))
+		require.True(t, h.Signals.Send(syscall.SIGTERM))
+		require.NoError(t, grace.Wait())
+	})
+}
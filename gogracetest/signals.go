@@ -0,0 +1,93 @@
+package gogracetest
+
+import (
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/itzloop/gograce"
+)
+
+// Signals is a gograce.SignalSource which only delivers the signals passed
+// to Send, the process is never signaled.
+type Signals struct {
+	mu   sync.Mutex
+	subs []*subscription
+}
+
+var _ gograce.SignalSource = (*Signals)(nil)
+
+type subscription struct {
+	c chan<- os.Signal
+
+	// signals is nil when c receives every signal.
+	signals []os.Signal
+}
+
+// NewSignals returns Signals without any channel to deliver to.
+func NewSignals() *Signals {
+	return &Signals{}
+}
+
+// Notify delivers sig to c from now on, or every signal if sig is empty.
+func (s *Signals) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		if sub.c != c {
+			continue
+		}
+
+		if sub.signals != nil {
+			sub.signals = append(sub.signals, sig...)
+		}
+
+		if len(sig) == 0 {
+			sub.signals = nil
+		}
+
+		return
+	}
+
+	sub := &subscription{c: c}
+	if len(sig) != 0 {
+		sub.signals = append([]os.Signal(nil), sig...)
+	}
+
+	s.subs = append(s.subs, sub)
+}
+
+// Stop stops delivering signals to c. When Stop returns, c receives no more signals.
+func (s *Signals) Stop(c chan<- os.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subs = slices.DeleteFunc(s.subs, func(sub *subscription) bool {
+		return sub.c == c
+	})
+}
+
+// Send delivers sig to every channel it was passed to Notify for and reports
+// whether there was any. Like the os/signal package, Send does not block, the
+// signal is dropped for channels that are full. Wait for a signal to be handled
+// before sending the next one.
+func (s *Signals) Send(sig os.Signal) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivered := false
+	for _, sub := range s.subs {
+		if sub.signals != nil && !slices.Contains(sub.signals, sig) {
+			continue
+		}
+
+		select {
+		case sub.c <- sig:
+			delivered = true
+		default:
+		}
+	}
+
+	return delivered
+}
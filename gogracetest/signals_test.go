@@ -0,0 +1,55 @@
+package gogracetest
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignals(t *testing.T) {
+	t.Run("notify", func(t *testing.T) {
+		var (
+			s    = NewSignals()
+			term = make(chan os.Signal, 1)
+			all  = make(chan os.Signal, 2)
+		)
+
+		s.Notify(term, syscall.SIGTERM)
+		s.Notify(all)
+
+		require.True(t, s.Send(syscall.SIGINT))
+		require.Equal(t, syscall.SIGINT, <-all)
+		require.Empty(t, term)
+
+		require.True(t, s.Send(syscall.SIGTERM))
+		require.Equal(t, syscall.SIGTERM, <-term)
+		require.Equal(t, syscall.SIGTERM, <-all)
+
+		// notifying the same channel again adds signals
+		s.Notify(term, syscall.SIGHUP)
+		require.True(t, s.Send(syscall.SIGHUP))
+		require.Equal(t, syscall.SIGHUP, <-term)
+	})
+
+	t.Run("full channel", func(t *testing.T) {
+		s := NewSignals()
+		c := make(chan os.Signal, 1)
+		s.Notify(c, syscall.SIGTERM)
+
+		require.True(t, s.Send(syscall.SIGTERM))
+		require.False(t, s.Send(syscall.SIGTERM))
+		require.Len(t, c, 1)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		s := NewSignals()
+		c := make(chan os.Signal, 1)
+		s.Notify(c, syscall.SIGTERM)
+		s.Stop(c)
+
+		require.False(t, s.Send(syscall.SIGTERM))
+		require.Empty(t, c)
+	})
+}
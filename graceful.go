@@ -2,8 +2,12 @@ package gograce
 
 import (
 	"context"
-	"log"
+	"io"
 	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -11,14 +15,34 @@ import (
 
 type Options struct {
 	// Timeout defines how long should the program wait before forcefully exiting.
+	// It is stopped once all go-routines and hooks have returned.
 	// a zero-value indicates no timeout.
 	Timeout time.Duration
 
+	// MaxTimeout limits how far ExtendTimeout can move the end of Timeout, measured from
+	// when shutdown began.
+	// a zero-value indicates no limit.
+	MaxTimeout time.Duration
+
 	// NoForceQuit disables the force quit feature. After the first termination signal, any further signals
-	// will be ignored.
+	// will be ignored. They stay subscribed to until the parent context is canceled, so they do not terminate
+	// the process either, even after Wait returned.
 	NoForceQuit bool
 
-	// MaxGoRoutines defines how many go-routines can be started. This value is passed to SetLimit on errgroup.Group.
+	// Escalation replaces what further termination signals do during graceful shutdown, e.g. skipping
+	// the shutdown phases that are not critical and shortening Timeout before quitting forcefully.
+	// It is passed to the SignalHandler, see SignalHandlerOptions.Escalation.
+	// a nil or empty value indicates the second signal quits forcefully.
+	Escalation []EscalationStep
+
+	// MinSignalInterval is the minimum time between two termination signals for the second one to
+	// escalate, see SignalHandlerOptions.MinSignalInterval.
+	// a zero-value indicates no minimum.
+	MinSignalInterval time.Duration
+
+	// MaxGoRoutines defines how many go-routines started by Go, GoWithContext and GoNamed can run at the
+	// same time, like SetLimit on errgroup.Group. Starting another one blocks until one of them returns.
+	// Go-routines of gograce itself, e.g. running the phases and components, do not count.
 	// a zero-value or negative indicates no limit.
 	MaxGoRoutines int
 
@@ -26,6 +50,90 @@ type Options struct {
 	// Signals let's you overwrite graceful.defaultSignals.
 	// a zero-value or an empty slice indicate no overwrite
 	Signals []os.Signal
+
+	// SignalActions maps signals to actions, e.g. a shorter Timeout for SIGINT or a stack
+	// dump for SIGQUIT. It is passed to the SignalHandler, see SignalHandlerOptions.Actions.
+	// SignalAction.Timeout is ignored, with a warning, when Timeout is zero.
+	// a nil value indicates every signal in Signals starts graceful shutdown.
+	SignalActions map[os.Signal]SignalAction
+
+	// ForceFunc is called when the application is forcefully quit, after the
+	// go-routines and hooks that are still running are logged. Wait returns
+	// ErrForced afterwards.
+	// a nil value indicates nothing is called.
+	ForceFunc ForceFunc
+
+	// TimeoutFunc is called when Timeout is reached, after the go-routines and
+	// hooks that are still running are logged. Wait returns ErrTimeout afterwards.
+	// a nil value indicates nothing is called.
+	TimeoutFunc TimeoutFunc
+
+	// ExitCodes configures the exit codes returned by Run.
+	ExitCodes ExitCodes
+
+	// StackDump, StackDumpFile and StackDumpTasksOnly are passed to the TimeoutHandler
+	// to write a stack dump of all go-routines when Timeout is reached.
+	// See TimeoutHandlerOptions for details. StackDump is also used by SignalDump actions.
+	StackDump          io.Writer
+	StackDumpFile      string
+	StackDumpTasksOnly bool
+
+	// Logger is used to log what happens during startup and shutdown and is
+	// passed to the SignalHandler and TimeoutHandler.
+	// If Logger is nil, NewSlogLogger(nil) will be used.
+	Logger Logger
+
+	// PreStopDelay is how long to wait after a signal is received before the context
+	// passed to go-routines is canceled. Readiness fails during this time so load
+	// balancers can stop sending traffic. It is part of Timeout, the delay is cut short
+	// when Timeout is reached or the application is forcefully quit.
+	// a zero-value indicates no delay.
+	PreStopDelay time.Duration
+
+	// RestartSignal enables zero-downtime restarts. When it is received a new process of the
+	// same binary is started with the listeners created by Listen, and graceful shutdown starts
+	// as if RestartSignal was one of Signals once the new process is ready. It should not be one of
+	// Signals. Only supported on unix.
+	// a nil value indicates restarts are disabled.
+	RestartSignal os.Signal
+
+	// RestartTimeout is how long to wait for the new process to be ready before giving up
+	// on the restart.
+	// a zero-value indicates defaultRestartTimeout.
+	RestartTimeout time.Duration
+
+	// SystemdNotify enables notifying systemd. When the application is started by systemd
+	// with Type=notify, READY=1 is sent once all components have started and STOPPING=1 together with
+	// EXTEND_TIMEOUT_USEC set to Timeout once shutdown starts. When the watchdog is enabled, WATCHDOG=1
+	// is sent periodically from the moment all components have started until Wait returns. See sd_notify(3).
+	// a zero-value indicates systemd is not notified.
+	SystemdNotify bool
+
+	// ReloadTimeout limits how long reloading each of the registered Reloaders may take.
+	// a zero-value indicates no timeout.
+	ReloadTimeout time.Duration
+
+	// Observers are notified about lifecycle events, see Observer.
+	Observers []Observer
+
+	// MaxFinishedTasks limits how many go-routines and hooks that returned without an
+	// error are kept for WaitReport, the oldest ones are dropped. Failed and running
+	// ones are always kept.
+	// a zero-value indicates defaultMaxFinishedTasks and a negative value no limit.
+	MaxFinishedTasks int
+
+	// CollectErrors makes Wait return the errors of all go-routines and hooks joined
+	// together as *TaskError, instead of only the first one. A failing phase or component
+	// is reported through the errors of its hooks or Start and Stop. Errors that do not
+	// belong to any of them, e.g. ErrUnknownDependency, are kept as well.
+	CollectErrors bool
+
+	// SignalSource and Clock are passed to the SignalHandler and TimeoutHandler, Clock is
+	// used by Graceful as well, e.g. for phase budgets. They are meant to be replaced in
+	// tests, see the gogracetest package.
+	// nil values indicate the os/signal and time packages are used.
+	SignalSource SignalSource
+	Clock        Clock
 }
 
 type Graceful struct {
@@ -34,8 +142,71 @@ type Graceful struct {
 	// TODO instead i'd like to do g.Go(f)
 	ctx context.Context
 	g   *errgroup.Group
+
+	// limit holds a value for every go-routine started by goLimited, see Options.MaxGoRoutines.
+	// a nil value indicates no limit.
+	limit chan struct{}
 	sh  *SignalHandler
 	th  *TimeoutHandler
+
+	// parent is the context passed to NewGracefulWithContext.
+	parent context.Context
+
+	// sigCtx is canceled by the SignalHandler, before Options.PreStopDelay.
+	sigCtx context.Context
+
+	// shutdownCtx is passed to cleanup hooks, see ShutdownContext.
+	shutdownCtx context.Context
+
+	opts   Options
+	logger Logger
+	clock  Clock
+
+	mu         sync.Mutex
+	phases     []*Phase
+	phasesDone bool
+	components []*component
+	started    []*component
+	tasks      []*task
+
+	// finished is the number of tasks that returned without an error, see pruneTasks.
+	finished int
+
+	shutdownOnce  sync.Once
+	shutdownStart time.Time
+	completeOnce  sync.Once
+
+	state atomic.Int32
+
+	// listeners are passed to the new process on restart.
+	listeners []restartListener
+
+	// restartCmd creates the command starting the new process on restart.
+	restartCmd func() (*exec.Cmd, error)
+
+	// notifySocket is the systemd notification socket, see sdNotify.
+	notifySocket string
+
+	reloaders []reloader
+	reloadMu  sync.Mutex
+
+	// skipNonCritical is set by an escalation step, see EscalationStep.SkipNonCritical.
+	skipNonCritical atomic.Bool
+
+	// waitCh is closed once the components have been started and all go-routines
+	// and hooks returned, with waitErr as the result. This happens only once no
+	// matter how often Wait is called.
+	waitOnce sync.Once
+	waitCh   chan struct{}
+	waitErr  error
+
+	// quitCh is closed when Wait has to return quitErr without waiting, see quit.
+	quitCh   chan struct{}
+	quitOnce sync.Once
+	quitErr  error
+
+	// forcedBy is the signal that forcefully quit the application.
+	forcedBy os.Signal
 }
 
 // NewGraceful calls NewGracefulWithContext with context.Background()
@@ -48,7 +219,7 @@ func NewGraceful(opts Options) *Graceful {
 func NewGracefulWithContext(ctx context.Context, opts Options) *Graceful {
 	var (
 		g        *errgroup.Group
-		graceful = &Graceful{}
+		graceful = &Graceful{parent: ctx, waitCh: make(chan struct{}), quitCh: make(chan struct{})}
 		signals  = defaultSignals[:]
 	)
 
@@ -57,27 +228,95 @@ func NewGracefulWithContext(ctx context.Context, opts Options) *Graceful {
 		signals = opts.Signals
 	}
 
+	if opts.Logger == nil {
+		opts.Logger = NewSlogLogger(nil)
+	}
+
+	if opts.SignalSource == nil {
+		opts.SignalSource = osSignals{}
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
+	graceful.opts = opts
+	graceful.logger = opts.Logger
+	graceful.clock = opts.Clock
+
+	if opts.SystemdNotify {
+		graceful.notifySocket = os.Getenv("NOTIFY_SOCKET")
+	}
+
 	// Create signal handler
 	graceful.sh, ctx = NewSignalHandler(ctx, SignalHandlerOptions{
-		Force:   !opts.NoForceQuit,
-		Signals: signals,
+		Force:             !opts.NoForceQuit,
+		Signals:           signals,
+		Actions:           opts.SignalActions,
+		Escalation:        opts.Escalation,
+		EscalateFunc:      graceful.escalate,
+		MinSignalInterval: opts.MinSignalInterval,
+		ForceFunc:         graceful.forceQuit,
+		ReloadFunc:        graceful.reload,
+		StackDump:         opts.StackDump,
+		Logger:            opts.Logger,
+		Observer:          ObserverFunc(graceful.observe),
+		SignalSource:      opts.SignalSource,
+		Clock:             opts.Clock,
 	})
 
+	if opts.Timeout == 0 {
+		for sig, action := range opts.SignalActions {
+			if action.Timeout != 0 {
+				graceful.logger.Warn("signal action timeout is ignored without a shutdown timeout", "signal", sig.String())
+			}
+		}
+	}
+
 	if opts.Timeout != 0 {
 		graceful.th = NewTimeoutHandler(ctx, TimeoutHandlerOptions{
-			Timeout: opts.Timeout,
+			Timeout:            opts.Timeout,
+			MaxTimeout:         opts.MaxTimeout,
+			TimeoutFunc:        graceful.timedOut,
+			StackDump:          opts.StackDump,
+			StackDumpFile:      opts.StackDumpFile,
+			StackDumpTasksOnly: opts.StackDumpTasksOnly,
+			Logger:             opts.Logger,
+			Observer:           ObserverFunc(graceful.observe),
+			Clock:              opts.Clock,
 		})
+		graceful.shutdownCtx = graceful.th.Context()
+	} else {
+		graceful.shutdownCtx = context.WithoutCancel(ctx)
+	}
+
+	graceful.sigCtx = ctx
+	if opts.RestartSignal != nil {
+		graceful.restartCmd = restartCmd
+		graceful.watchRestart(ctx, opts.RestartSignal)
+	}
+
+	if opts.PreStopDelay > 0 {
+		ctx = graceful.preStop(ctx, opts.PreStopDelay)
 	}
 
 	g, ctx = errgroup.WithContext(ctx)
 
-	if opts.MaxGoRoutines != 0 {
-		g.SetLimit(opts.MaxGoRoutines)
+	if opts.MaxGoRoutines > 0 {
+		graceful.limit = make(chan struct{}, opts.MaxGoRoutines)
 	}
 
 	graceful.g = g
 	graceful.ctx = ctx
 
+	go func() {
+		<-ctx.Done()
+		graceful.setState(StateDraining)
+		graceful.startShutdown()
+	}()
+
+	graceful.sdWatchdog()
+
 	return graceful
 }
 
@@ -85,24 +324,195 @@ func NewGracefulWithContext(ctx context.Context, opts Options) *Graceful {
 // accepts a functions that takes a context as input instead of not
 // having any input.
 func (grace *Graceful) GoWithContext(f func(ctx context.Context) error) {
+	grace.GoNamed(funcName(f), f)
+}
+
+// GoNamed is like GoWithContext but names the go-routine, the name
+// is used by Running, WaitReport and in errors.
+func (grace *Graceful) GoNamed(name string, f func(ctx context.Context) error) {
+	t := grace.newTask(name)
+	grace.goLimited(func() error {
+		return grace.runTask(t, func() error {
+			return f(grace.ctx)
+		})
+	})
+}
+
+// OnShutdown registers f as a cleanup hook. f is called once the context of grace
+// is canceled, with the shutdown context instead of the canceled one.
+func (grace *Graceful) OnShutdown(f HookFunc) {
+	t := grace.newTask(funcName(f))
 	grace.g.Go(func() error {
-		return f(grace.ctx)
+		<-grace.ctx.Done()
+		grace.startShutdown()
+		return grace.runTask(t, func() error {
+			return f(grace.shutdownCtx)
+		})
 	})
 }
 
+// ShutdownContext returns the context that is passed to cleanup hooks. It is not
+// canceled when shutdown begins, but only when Options.Timeout is reached, and
+// once shutdown has begun its deadline is the end of Options.Timeout. Without
+// a timeout it is never canceled.
+func (grace *Graceful) ShutdownContext() context.Context {
+	return grace.shutdownCtx
+}
+
+// ExtendTimeout moves the end of Options.Timeout d later, e.g. when a long migration
+// needs more time, but not beyond Options.MaxTimeout after shutdown began. Once shutdown
+// has begun systemd is told about the new deadline. It has no effect without a timeout.
+func (grace *Graceful) ExtendTimeout(d time.Duration) {
+	if grace.th == nil {
+		return
+	}
+
+	grace.th.Extend(d)
+	if grace.sigCtx.Err() != nil {
+		grace.sdNotify("EXTEND_TIMEOUT_USEC=" + strconv.FormatInt(grace.th.Remaining().Microseconds(), 10))
+	}
+}
+
+// ShortenTimeout moves the end of Options.Timeout so that at most d is left, e.g. half
+// of RemainingTimeout. It has no effect when less is left already or without a timeout.
+func (grace *Graceful) ShortenTimeout(d time.Duration) {
+	if grace.th != nil {
+		grace.th.Shorten(d)
+	}
+}
+
+// RemainingTimeout returns how much of Options.Timeout is left, which is all of it
+// before shutdown begins. ok is false without a timeout.
+func (grace *Graceful) RemainingTimeout() (remaining time.Duration, ok bool) {
+	if grace.th == nil {
+		return 0, false
+	}
+
+	return grace.th.Remaining(), true
+}
+
+// Shutdown starts graceful shutdown from within the application, e.g. from an admin
+// endpoint or on a fatal error. Like a received signal, the context of grace is canceled
+// with reason as cause, Options.Timeout applies and a signal received afterwards quits
+// forcefully. reason is not returned by Wait, see context.Cause. Calling Shutdown
+// once graceful shutdown has started has no effect.
+func (grace *Graceful) Shutdown(reason error) {
+	grace.sh.Shutdown(reason)
+}
+
+// Logger returns Options.Logger, or the default Logger if it was not set.
+func (grace *Graceful) Logger() Logger {
+	return grace.logger
+}
+
 // Go calls (*errgroup.Group).Go() internally
 func (grace *Graceful) Go(f func() error) {
-	grace.g.Go(f)
+	t := grace.newTask(funcName(f))
+	grace.goLimited(func() error {
+		return grace.runTask(t, f)
+	})
+}
+
+// goLimited calls f on a new go-routine of the errgroup.Group once fewer than
+// Options.MaxGoRoutines go-routines started by it are running. Go-routines of
+// gograce itself are started with grace.g.Go so they never wait for a slot.
+func (grace *Graceful) goLimited(f func() error) {
+	if grace.limit == nil {
+		grace.g.Go(f)
+		return
+	}
+
+	grace.limit <- struct{}{}
+	grace.g.Go(func() error {
+		defer func() { <-grace.limit }()
+		return f()
+	})
 }
 
-// Wait calls (*errgroup.Group).Wait() and returns the error
+// Wait starts the registered components and then calls (*errgroup.Group).Wait()
+// and returns the error. If Options.CollectErrors is set, errors of all go-routines
+// and hooks are returned instead. When the application is forcefully quit or
+// Options.Timeout is reached, Wait returns ErrForced or ErrTimeout right away.
 func (grace *Graceful) Wait() error {
-	return grace.g.Wait()
+	grace.waitOnce.Do(func() {
+		go func() {
+			grace.startComponents()
+			grace.waitErr = grace.g.Wait()
+
+			// everything returned, the timeout must not be reached afterwards,
+			// e.g. while deferred functions in main run
+			if grace.th != nil {
+				grace.th.Stop()
+			}
+
+			close(grace.waitCh)
+		}()
+	})
+
+	var err error
+	select {
+	case <-grace.waitCh:
+		err = grace.waitErr
+		if grace.opts.CollectErrors {
+			err = grace.collectErrors(err)
+		}
+	case <-grace.quitCh:
+		err = grace.quitErr
+	}
+
+	grace.setState(StateStopped)
+	grace.completeOnce.Do(func() {
+		// the context is canceled when Wait returns, make sure
+		// EventShutdownStarted is emitted before EventShutdownCompleted
+		grace.startShutdown()
+
+		grace.mu.Lock()
+		elapsed := grace.clock.Now().Sub(grace.shutdownStart)
+		grace.mu.Unlock()
+
+		grace.observe(Event{Kind: EventShutdownCompleted, Duration: elapsed, Err: err})
+	})
+
+	return err
+}
+
+// forceQuit logs the tasks that are still running and calls Options.ForceFunc
+// before making Wait return ErrForced.
+func (grace *Graceful) forceQuit() {
+	grace.logRunning()
+	if grace.opts.ForceFunc != nil {
+		grace.opts.ForceFunc()
+	}
+
+	grace.quit(ErrForced)
+}
+
+// timedOut logs the tasks that are still running and calls Options.TimeoutFunc
+// before making Wait return ErrTimeout.
+func (grace *Graceful) timedOut() {
+	grace.logRunning()
+	if grace.opts.TimeoutFunc != nil {
+		grace.opts.TimeoutFunc()
+	}
+
+	grace.quit(ErrTimeout)
+}
+
+// escalate is the SignalHandlerOptions.EscalateFunc of Graceful.
+func (grace *Graceful) escalate(sig os.Signal, step EscalationStep) {
+	if step.Timeout > 0 && grace.th != nil {
+		grace.th.Shorten(step.Timeout)
+	}
+
+	if step.SkipNonCritical {
+		grace.skipNonCritical.Store(true)
+	}
 }
 
-// FatalWait calls Wait but log.Fatals when an error is received
+// FatalWait calls Run and exits with the exit code it returns, unless it is 0.
+// Deferred functions are not run when it exits, see Run.
 func (grace *Graceful) FatalWait() {
-	if err := grace.Wait(); err != nil {
-		log.Fatalln(err.Error())
+	if code := grace.Run(); code != 0 {
+		os.Exit(code)
 	}
 }
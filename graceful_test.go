@@ -1,13 +1,18 @@
 package gograce
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log/slog"
+	"os"
 	"sync"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGracefulForce(t *testing.T) {
@@ -20,9 +25,11 @@ func TestGracefulForce(t *testing.T) {
 		ended             bool
 		forceHanlerCalled bool
 		wg                = sync.WaitGroup{}
+		startedCh         = make(chan struct{})
 	)
 
 	grace.GoWithContext(func(ctx context.Context) error {
+		defer close(startedCh)
 		started = true
 		return nil
 	})
@@ -40,6 +47,9 @@ func TestGracefulForce(t *testing.T) {
 	}
 
 	go func() {
+		// make sure the first go-routine has run before force quitting, it might
+		// not have been scheduled yet when other tests ran before in the same process
+		<-startedCh
 		grace.sh.sigChan <- syscall.SIGINT
 		grace.sh.sigChan <- syscall.SIGINT
 	}()
@@ -158,3 +168,551 @@ func TestGracefulAppExits(t *testing.T) {
 	assert.True(t, started)
 	assert.True(t, ended)
 }
+
+func TestGracefulTimeoutAfterWait(t *testing.T) {
+	var (
+		observer = &testObserver{}
+		timedOut = make(chan struct{})
+		grace    = NewGracefulWithContext(context.Background(), Options{
+			Logger:      NopLogger(),
+			Timeout:     50 * time.Millisecond,
+			TimeoutFunc: func() { close(timedOut) },
+			Observers:   []Observer{observer},
+		})
+	)
+
+	grace.GoWithContext(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	grace.sh.sigChan <- syscall.SIGINT
+	require.NoError(t, grace.Wait())
+
+	// e.g. deferred functions in main taking longer than what is left of the timeout
+	select {
+	case <-timedOut:
+		t.Fatal("timeout reached after Wait returned")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NotContains(t, observer.kinds(), EventTimeout)
+}
+
+func TestGracefulMaxGoRoutines(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		events      []string
+		ctx, cancel = context.WithCancel(context.Background())
+		grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), MaxGoRoutines: 1})
+		started     = make(chan struct{})
+	)
+
+	// none of them takes the only slot
+	grace.Phase("close", PhaseOptions{}).Hook(func(ctx context.Context) error { return nil })
+	grace.OnShutdown(func(ctx context.Context) error { return nil })
+	grace.Register("db", &testComponent{name: "db", mu: &mu, events: &events})
+
+	go func() {
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		close(started)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("go-routine waits for a slot taken by gograce")
+	}
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- grace.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		return grace.State() == StateRunning
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-errCh)
+	require.Equal(t, []string{"start db", "stop db"}, events)
+
+	t.Run("limited", func(t *testing.T) {
+		var (
+			grace   = NewGracefulWithContext(context.Background(), Options{Logger: NopLogger(), MaxGoRoutines: 1})
+			release = make(chan struct{})
+			second  = make(chan struct{})
+		)
+
+		grace.Go(func() error {
+			<-release
+			return nil
+		})
+
+		go func() {
+			grace.Go(func() error { return nil })
+			close(second)
+		}()
+
+		select {
+		case <-second:
+			t.Fatal("second go-routine started although the limit is reached")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		<-second
+		require.NoError(t, grace.Wait())
+	})
+}
+
+func TestGracefulOnShutdown(t *testing.T) {
+	t.Run("with timeout", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Timeout: time.Second,
+		})
+
+		grace.th.timeoutFunc = func() {}
+
+		var (
+			runErr      error
+			shutdownErr error
+			deadline    time.Time
+			ok          bool
+		)
+
+		grace.OnShutdown(func(ctx context.Context) error {
+			runErr = grace.ctx.Err()
+			shutdownErr = ctx.Err()
+			deadline, ok = ctx.Deadline()
+			return nil
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		require.NoError(t, grace.Wait())
+		require.ErrorIs(t, runErr, context.Canceled)
+		require.NoError(t, shutdownErr)
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("without timeout", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{})
+
+		var (
+			shutdownErr error
+			ok          bool
+		)
+
+		grace.OnShutdown(func(ctx context.Context) error {
+			shutdownErr = ctx.Err()
+			_, ok = ctx.Deadline()
+			return nil
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		require.NoError(t, grace.Wait())
+		require.NoError(t, shutdownErr)
+		require.False(t, ok)
+	})
+}
+
+func TestGracefulCollectErrors(t *testing.T) {
+	var (
+		errBackup   = errors.New("backup failed")
+		errShutdown = errors.New("shutdown failed")
+		backup      = func(ctx context.Context) error {
+			return errBackup
+		}
+		shutdown = func(ctx context.Context) error {
+			return errShutdown
+		}
+	)
+
+	t.Run("first error", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{})
+		grace.GoWithContext(backup)
+		grace.OnShutdown(shutdown)
+
+		err := grace.Wait()
+		require.ErrorIs(t, err, errBackup)
+		require.NotErrorIs(t, err, errShutdown)
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			CollectErrors: true,
+		})
+		grace.GoWithContext(backup)
+		grace.OnShutdown(shutdown)
+		grace.GoWithContext(func(ctx context.Context) error {
+			return nil
+		})
+
+		err := grace.Wait()
+		require.ErrorIs(t, err, errBackup)
+		require.ErrorIs(t, err, errShutdown)
+
+		var taskErr *TaskError
+		require.ErrorAs(t, err, &taskErr)
+		require.Equal(t, "github.com/itzloop/gograce.TestGracefulCollectErrors.func1", taskErr.Name)
+		require.Equal(t, errBackup, taskErr.Err)
+
+		require.EqualError(t, err, ""+
+			"github.com/itzloop/gograce.TestGracefulCollectErrors.func1: backup failed\n"+
+			"github.com/itzloop/gograce.TestGracefulCollectErrors.func2: shutdown failed")
+	})
+
+	t.Run("errors that do not belong to a task", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			CollectErrors: true,
+		})
+		grace.Register("a", &testComponent{}, "b")
+
+		require.ErrorIs(t, grace.Wait(), ErrUnknownDependency)
+	})
+
+	t.Run("errors of tasks and others", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:        NopLogger(),
+			CollectErrors: true,
+		})
+
+		grace.Register("a", &testComponent{}, "b")
+		grace.OnShutdown(shutdown)
+
+		err := grace.Wait()
+		require.ErrorIs(t, err, ErrUnknownDependency)
+		require.ErrorIs(t, err, errShutdown)
+	})
+
+	t.Run("errors of phases and components", func(t *testing.T) {
+		var (
+			mu     sync.Mutex
+			events []string
+			grace  = NewGracefulWithContext(context.Background(), Options{
+				Logger:        NopLogger(),
+				CollectErrors: true,
+			})
+		)
+
+		grace.Phase("drain", PhaseOptions{}).HookNamed("db", shutdown)
+		grace.Register("cache", &testComponent{name: "cache", mu: &mu, events: &events, stopErr: errBackup})
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- grace.Wait()
+		}()
+
+		require.Eventually(t, func() bool {
+			return grace.State() == StateRunning
+		}, time.Second, time.Millisecond)
+		grace.Shutdown(errors.New("test"))
+
+		// the errors wrapped by the phase and the component are only reported by their tasks
+		require.EqualError(t, <-errCh, ""+
+			"drain/db: shutdown failed\n"+
+			"cache.Stop: backup failed")
+	})
+}
+
+func TestGracefulRunning(t *testing.T) {
+	var (
+		wg      = sync.WaitGroup{}
+		running []string
+		block   = make(chan struct{})
+		grace   *Graceful
+	)
+
+	defer close(block)
+
+	wg.Add(1)
+	grace = NewGracefulWithContext(context.Background(), Options{
+		Timeout: 100 * time.Millisecond,
+		TimeoutFunc: func() {
+			defer wg.Done()
+			running = grace.Running()
+		},
+	})
+
+	grace.GoNamed("server", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	grace.GoNamed("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		<-block
+		return nil
+	})
+
+	grace.Phase("flush", PhaseOptions{}).Hook(func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		return len(grace.Running()) == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, []string{"server", "worker"}, grace.Running())
+
+	grace.sh.sigChan <- syscall.SIGINT
+
+	wg.Wait()
+	require.Equal(t, []string{"worker", "flush/github.com/itzloop/gograce.TestGracefulRunning.func4"}, running)
+}
+
+func TestGracefulStackDump(t *testing.T) {
+	var (
+		wg    = sync.WaitGroup{}
+		buf   bytes.Buffer
+		block = make(chan struct{})
+	)
+
+	defer close(block)
+
+	wg.Add(1)
+	grace := NewGracefulWithContext(context.Background(), Options{
+		Timeout:            10 * time.Millisecond,
+		StackDump:          &buf,
+		StackDumpTasksOnly: true,
+		TimeoutFunc: func() {
+			wg.Done()
+		},
+	})
+
+	grace.GoNamed("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		<-block
+		return nil
+	})
+
+	grace.sh.sigChan <- syscall.SIGINT
+	wg.Wait()
+
+	require.Contains(t, buf.String(), `# labels: {"gograce_task":"worker"}`)
+	require.NotContains(t, buf.String(), "gograce.TestGracefulStackDump+")
+}
+
+func TestGracefulSignalActions(t *testing.T) {
+	var (
+		timedOut = make(chan struct{})
+		grace    = NewGracefulWithContext(context.Background(), Options{
+			Logger:  NopLogger(),
+			Timeout: time.Hour,
+			SignalActions: map[os.Signal]SignalAction{
+				syscall.SIGINT: {Kind: SignalShutdown, Timeout: 50 * time.Millisecond},
+			},
+		})
+	)
+
+	grace.th.timeoutFunc = func() {
+		close(timedOut)
+	}
+
+	grace.OnShutdown(func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 50*time.Millisecond)
+
+		<-ctx.Done()
+		return nil
+	})
+
+	start := time.Now()
+	grace.sh.sigChan <- syscall.SIGINT
+
+	<-timedOut
+	require.Less(t, time.Since(start), time.Second)
+	require.NoError(t, grace.Wait())
+}
+
+func TestGracefulSignalActionsWithoutTimeout(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		grace = NewGracefulWithContext(context.Background(), Options{
+			Logger: NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+			SignalActions: map[os.Signal]SignalAction{
+				syscall.SIGINT: {Kind: SignalShutdown, Timeout: 50 * time.Millisecond},
+			},
+		})
+	)
+
+	// there is no timeout the action could replace
+	require.Nil(t, grace.th)
+	require.Contains(t, buf.String(), "signal action timeout is ignored without a shutdown timeout")
+	require.Contains(t, buf.String(), "signal=interrupt")
+
+	grace.sh.sigChan <- syscall.SIGINT
+	require.NoError(t, grace.Wait())
+}
+
+func TestGracefulContextCause(t *testing.T) {
+	t.Run("signal", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:       NopLogger(),
+			PreStopDelay: time.Millisecond,
+		})
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+
+			// the cause is kept across the pre-stop delay
+			var se *SignalError
+			assert.ErrorAs(t, context.Cause(ctx), &se)
+
+			sig, ok := SignalFromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, syscall.SIGINT, sig)
+			return nil
+		})
+
+		grace.OnShutdown(func(ctx context.Context) error {
+			sig, ok := SignalFromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, syscall.SIGINT, sig)
+			return nil
+		})
+
+		grace.sh.sigChan <- syscall.SIGINT
+		require.NoError(t, grace.Wait())
+	})
+
+	t.Run("go-routine failed", func(t *testing.T) {
+		var (
+			errFailed = errors.New("failed")
+			grace     = NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		)
+
+		grace.Go(func() error {
+			return errFailed
+		})
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+
+			_, ok := SignalFromContext(ctx)
+			assert.False(t, ok)
+			assert.ErrorIs(t, context.Cause(ctx), errFailed)
+			return nil
+		})
+
+		require.ErrorIs(t, grace.Wait(), errFailed)
+	})
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	errMaintenance := errors.New("maintenance")
+
+	t.Run("reason", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+
+			assert.ErrorIs(t, context.Cause(ctx), errMaintenance)
+			_, ok := SignalFromContext(ctx)
+			assert.False(t, ok)
+			return nil
+		})
+
+		grace.Shutdown(errMaintenance)
+		grace.Shutdown(errors.New("ignored"))
+
+		require.NoError(t, grace.Wait())
+		require.Nil(t, grace.sh.Signal())
+	})
+
+	t.Run("signal forces", func(t *testing.T) {
+		var (
+			draining = make(chan struct{})
+			block    = make(chan struct{})
+			grace    = NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		)
+
+		defer close(block)
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			close(draining)
+			<-block
+			return nil
+		})
+
+		grace.Shutdown(errMaintenance)
+		<-draining
+
+		grace.sh.sigChan <- syscall.SIGTERM
+		require.ErrorIs(t, grace.Wait(), ErrForced)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		block := make(chan struct{})
+		defer close(block)
+
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:  NopLogger(),
+			Timeout: 10 * time.Millisecond,
+		})
+
+		grace.OnShutdown(func(ctx context.Context) error {
+			<-block
+			return nil
+		})
+
+		grace.Shutdown(errMaintenance)
+		require.ErrorIs(t, grace.Wait(), ErrTimeout)
+	})
+}
+
+func TestGracefulAdjustTimeout(t *testing.T) {
+	t.Run("no timeout", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		grace.ExtendTimeout(time.Minute)
+		grace.ShortenTimeout(time.Minute)
+
+		_, ok := grace.RemainingTimeout()
+		require.False(t, ok)
+	})
+
+	t.Run("later phases", func(t *testing.T) {
+		var (
+			budgets     = make(chan time.Duration, 2)
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), Timeout: time.Minute})
+		)
+
+		grace.Phase("migrate", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			budgets <- time.Until(deadline)
+
+			// the migration needs more time
+			grace.ExtendTimeout(time.Hour)
+			return nil
+		})
+
+		grace.Phase("close", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			budgets <- time.Until(deadline)
+			return nil
+		})
+
+		remaining, ok := grace.RemainingTimeout()
+		require.True(t, ok)
+		require.Equal(t, time.Minute, remaining)
+
+		cancel()
+		require.NoError(t, grace.Wait())
+		require.InDelta(t, 30*time.Second, <-budgets, float64(time.Second))
+		require.InDelta(t, 61*time.Minute, <-budgets, float64(time.Second))
+	})
+}
@@ -0,0 +1,120 @@
+// Package grpcgrace runs a gRPC server with gograce.
+//
+// It lives in its own module so gograce itself does not depend on gRPC. The server
+// is stopped with GracefulStop once shutdown starts, or once a shutdown phase is
+// reached, and with Stop when what is left of gograce.Options.Timeout runs out.
+//
+//	grpcgrace.Serve(grace, srv, l, grpcgrace.Options{Phase: "drain"})
+package grpcgrace
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/itzloop/gograce"
+	"google.golang.org/grpc"
+)
+
+// Options
+type Options struct {
+	// Name is used as the name of the task serving the server.
+	// an empty value indicates "grpc " followed by the listen address.
+	Name string
+
+	// Phase is the name of the shutdown phase the server is stopped in.
+	// an empty value indicates the server is stopped as soon as shutdown starts.
+	Phase string
+}
+
+// Server is a *grpc.Server run by gograce.Graceful.
+type Server struct {
+	srv    *grpc.Server
+	opts   Options
+	logger gograce.Logger
+
+	forced atomic.Bool
+}
+
+// Serve runs srv on l until shutdown. srv should not be started or stopped by the caller.
+func Serve(grace *gograce.Graceful, srv *grpc.Server, l net.Listener, opts Options) *Server {
+	if opts.Name == "" {
+		opts.Name = "grpc " + l.Addr().String()
+	}
+
+	s := &Server{
+		srv:    srv,
+		opts:   opts,
+		logger: grace.Logger(),
+	}
+
+	if opts.Phase != "" {
+		grace.Phase(opts.Phase, gograce.PhaseOptions{}).HookNamed(opts.Name, s.Stop)
+	}
+
+	grace.GoNamed(opts.Name, func(ctx context.Context) error {
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- srv.Serve(l)
+		}()
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+		}
+
+		var err error
+		if opts.Phase == "" {
+			err = s.Stop(grace.ShutdownContext())
+		}
+
+		// Serve returns ErrServerStopped when a phase stopped the server before it was called
+		if serveErr := <-errCh; !errors.Is(serveErr, grpc.ErrServerStopped) {
+			err = errors.Join(err, serveErr)
+		}
+
+		return err
+	})
+
+	return s
+}
+
+// Server returns the underlying *grpc.Server.
+func (s *Server) Server() *grpc.Server {
+	return s.srv
+}
+
+// Forced reports whether the server was stopped forcefully
+// because RPCs were still running when the deadline was reached.
+func (s *Server) Forced() bool {
+	return s.forced.Load()
+}
+
+// Stop gracefully stops the server and waits for the running RPCs to finish. Once
+// ctx is done the server is stopped forcefully, canceling the RPCs that are still
+// running. It always returns nil so it can be used as a gograce.HookFunc.
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("stopping grpc server", "server", s.opts.Name)
+
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.forced.Store(true)
+	s.logger.Warn("force stopping grpc server", "server", s.opts.Name)
+
+	// Stop makes GracefulStop return as well
+	s.srv.Stop()
+	<-done
+	return nil
+}
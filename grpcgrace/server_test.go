@@ -0,0 +1,111 @@
+package grpcgrace
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/itzloop/gograce"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestServer(t *testing.T) (*grpc.Server, net.Listener, healthpb.HealthClient) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return srv, l, healthpb.NewHealthClient(conn)
+}
+
+func TestServe(t *testing.T) {
+	t.Run("graceful stop", func(t *testing.T) {
+		var (
+			srv, l, client = newTestServer(t)
+			ctx, cancel    = context.WithCancel(context.Background())
+			grace          = gograce.NewGracefulWithContext(ctx, gograce.Options{Logger: gograce.NopLogger()})
+			s              = Serve(grace, srv, l, Options{})
+		)
+
+		require.Equal(t, "grpc "+l.Addr().String(), s.opts.Name)
+
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		cancel()
+		require.NoError(t, grace.Wait())
+		require.False(t, s.Forced())
+
+		_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.Error(t, err)
+	})
+
+	t.Run("force stop", func(t *testing.T) {
+		var (
+			srv, l, client = newTestServer(t)
+			ctx, cancel    = context.WithCancel(context.Background())
+			grace          = gograce.NewGracefulWithContext(ctx, gograce.Options{
+				Logger:  gograce.NopLogger(),
+				Timeout: 100 * time.Millisecond,
+			})
+			s = Serve(grace, srv, l, Options{Name: "api"})
+		)
+
+		// Watch streams until the server is stopped, so GracefulStop never returns
+		stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		start := time.Now()
+		cancel()
+
+		// Wait does not wait for the server to be stopped once the timeout is reached
+		require.ErrorIs(t, grace.Wait(), gograce.ErrTimeout)
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		require.Eventually(t, s.Forced, time.Second, time.Millisecond)
+
+		_, err = stream.Recv()
+		require.Error(t, err)
+	})
+
+	t.Run("phase", func(t *testing.T) {
+		var (
+			srv, l, client = newTestServer(t)
+			ctx, cancel    = context.WithCancel(context.Background())
+			grace          = gograce.NewGracefulWithContext(ctx, gograce.Options{Logger: gograce.NopLogger()})
+			served         = make(chan error, 1)
+		)
+
+		// the server still serves until the drain phase is reached
+		grace.Phase("flush", gograce.PhaseOptions{}).Hook(func(ctx context.Context) error {
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			served <- err
+			return nil
+		})
+
+		Serve(grace, srv, l, Options{Phase: "drain"})
+
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		cancel()
+		require.NoError(t, grace.Wait())
+		require.NoError(t, <-served)
+
+		_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.Error(t, err)
+	})
+}
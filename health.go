@@ -0,0 +1,135 @@
+package gograce
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// State is the lifecycle state of Graceful.
+type State int32
+
+const (
+	// StateStarting is the state until Wait has started all components.
+	StateStarting State = iota
+
+	// StateRunning is the state once all components have been started.
+	StateRunning
+
+	// StateDraining is the state from the moment shutdown is triggered until Wait returns.
+	// It includes Options.PreStopDelay.
+	StateDraining
+
+	// StateStopped is the state once Wait has returned.
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current lifecycle state.
+func (grace *Graceful) State() State {
+	s := State(grace.state.Load())
+
+	// the contexts are canceled before the go-routines updating the state get to run
+	if s < StateDraining && (grace.sigCtx.Err() != nil || grace.ctx.Err() != nil) {
+		return StateDraining
+	}
+
+	return s
+}
+
+// setState moves to s. States only move forward, so moving to
+// a state that is behind the current one has no effect.
+func (grace *Graceful) setState(s State) {
+	for {
+		current := grace.state.Load()
+		if current >= int32(s) {
+			return
+		}
+
+		if grace.state.CompareAndSwap(current, int32(s)) {
+			grace.logger.Debug("state changed", "from", State(current).String(), "to", s.String())
+			return
+		}
+	}
+}
+
+// ReadyHandler returns an http.Handler for readiness probes. It responds with
+// 200 while in StateRunning and with 503 otherwise, so readiness fails as soon as
+// shutdown is triggered.
+func (grace *Graceful) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := grace.State()
+		writeHealth(w, state, state == StateRunning)
+	})
+}
+
+// LiveHandler returns an http.Handler for liveness probes. It responds with 200
+// until Wait has returned and with 503 afterward.
+func (grace *Graceful) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := grace.State()
+		writeHealth(w, state, state != StateStopped)
+	})
+}
+
+// HealthHandler returns an http.Handler serving ReadyHandler on /readyz
+// and LiveHandler on /livez.
+func (grace *Graceful) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", grace.ReadyHandler())
+	mux.Handle("/livez", grace.LiveHandler())
+	return mux
+}
+
+func writeHealth(w http.ResponseWriter, state State, ok bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_, _ = w.Write([]byte(state.String()))
+}
+
+// preStop returns a context that is canceled delay after ctx is done. It carries
+// the values of ctx. This gives load balancers time to notice the failing readiness
+// before the application starts shutting down. The delay is cut short when the
+// application quits forcefully or reaches its timeout.
+func (grace *Graceful) preStop(ctx context.Context, delay time.Duration) context.Context {
+	runCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+
+	go func() {
+		<-ctx.Done()
+		grace.setState(StateDraining)
+		grace.logger.Info("waiting for pre-stop delay", "delay", delay)
+
+		elapsed := make(chan struct{})
+		timer := grace.clock.AfterFunc(delay, func() { close(elapsed) })
+		defer timer.Stop()
+
+		select {
+		case <-elapsed:
+		case <-grace.quitCh:
+			grace.logger.Warn("pre-stop delay cut short")
+		}
+
+		cancel(context.Cause(ctx))
+	}()
+
+	return runCtx
+}
@@ -0,0 +1,174 @@
+package gograce
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestHealth(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger()})
+		h           = grace.HealthHandler()
+		running     = make(chan struct{})
+	)
+
+	code, body := probe(t, h, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "starting", body)
+
+	code, _ = probe(t, h, "/livez")
+	require.Equal(t, http.StatusOK, code)
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+
+	grace.Register("db", &testComponent{name: "db", mu: &mu, events: &events})
+
+	var (
+		readyCode, liveCode int
+		readyBody           string
+	)
+
+	grace.GoWithContext(func(ctx context.Context) error {
+		close(running)
+		<-ctx.Done()
+
+		// readiness fails as soon as shutdown is triggered
+		readyCode, readyBody = probe(t, h, "/readyz")
+		liveCode, _ = probe(t, h, "/livez")
+		return nil
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- grace.Wait()
+	}()
+
+	<-running
+	require.Eventually(t, func() bool {
+		return grace.State() == StateRunning
+	}, time.Second, time.Millisecond)
+
+	code, body = probe(t, h, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "running", body)
+
+	cancel()
+	require.NoError(t, <-errCh)
+	require.Equal(t, StateStopped, grace.State())
+
+	require.Equal(t, http.StatusServiceUnavailable, readyCode)
+	require.Equal(t, "draining", readyBody)
+	require.Equal(t, http.StatusOK, liveCode)
+
+	code, body = probe(t, h, "/livez")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "stopped", body)
+
+	code, _ = probe(t, h, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestHealthFailedStart(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+
+	grace := NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+	grace.Register("db", &testComponent{name: "db", mu: &mu, events: &events, startErr: context.DeadlineExceeded})
+
+	require.Error(t, grace.Wait())
+	require.Equal(t, StateStopped, grace.State())
+}
+
+func TestPreStopDelay(t *testing.T) {
+	var (
+		delay       = 100 * time.Millisecond
+		ctx, cancel = context.WithCancel(context.Background())
+		grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), PreStopDelay: delay})
+		canceled    = make(chan time.Time, 1)
+	)
+
+	grace.GoWithContext(func(ctx context.Context) error {
+		<-ctx.Done()
+		canceled <- time.Now()
+		return nil
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- grace.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		return grace.State() == StateRunning
+	}, time.Second, time.Millisecond)
+
+	start := time.Now()
+	cancel()
+
+	require.Eventually(t, func() bool {
+		return grace.State() == StateDraining
+	}, time.Second, time.Millisecond)
+
+	code, _ := probe(t, grace.ReadyHandler(), "/")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	require.NoError(t, <-errCh)
+	require.GreaterOrEqual(t, (<-canceled).Sub(start), delay)
+}
+
+func TestPreStopDelayTimeout(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		grace       = NewGracefulWithContext(ctx, Options{
+			Logger:       NopLogger(),
+			Timeout:      50 * time.Millisecond,
+			TimeoutFunc:  func() {},
+			PreStopDelay: time.Hour,
+		})
+		canceled = make(chan struct{})
+	)
+
+	grace.GoWithContext(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		return nil
+	})
+
+	cancel()
+	require.ErrorIs(t, grace.Wait(), ErrTimeout)
+
+	// the delay is cut short so go-routines are not left running
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("context was not canceled")
+	}
+}
+
+func TestStateString(t *testing.T) {
+	require.Equal(t, "starting", StateStarting.String())
+	require.Equal(t, "running", StateRunning.String())
+	require.Equal(t, "draining", StateDraining.String())
+	require.Equal(t, "stopped", StateStopped.String())
+	require.Equal(t, "unknown", State(42).String())
+}
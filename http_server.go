@@ -0,0 +1,191 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// HTTPServerOptions
+type HTTPServerOptions struct {
+	// Name is used as the name of the task serving the server.
+	// an empty value indicates "http " followed by the listen address.
+	Name string
+
+	// Listener is the listener the server accepts connections on.
+	// a nil value indicates http.Server.Addr is listened on.
+	Listener net.Listener
+
+	// CertFile and KeyFile are passed to http.Server.ServeTLS.
+	// empty values indicate http.Server.Serve is used unless
+	// http.Server.TLSConfig has certificates.
+	CertFile string
+	KeyFile  string
+
+	// Phase is the name of the shutdown phase the server is shut down in.
+	// an empty value indicates the server is shut down as soon as shutdown starts.
+	Phase string
+}
+
+// HTTPServer is an *http.Server run by Graceful. It is shut down when shutdown starts,
+// or when Phase is reached, with what is left of Options.Timeout. Connections that are
+// still open once the deadline is reached are closed forcefully.
+type HTTPServer struct {
+	srv   *http.Server
+	opts  HTTPServerOptions
+	grace *Graceful
+
+	mu          sync.Mutex
+	conns       map[net.Conn]struct{}
+	forceClosed int
+}
+
+// HTTPServer runs srv until shutdown. http.Server.BaseContext defaults to the
+// shutdown context, so requests are canceled when Options.Timeout is reached,
+// and http.Server.ConnState is wrapped to keep track of open connections.
+// srv should not be started or shut down by the caller.
+func (grace *Graceful) HTTPServer(srv *http.Server, opts HTTPServerOptions) *HTTPServer {
+	s := &HTTPServer{
+		srv:   srv,
+		opts:  opts,
+		grace: grace,
+		conns: make(map[net.Conn]struct{}),
+	}
+
+	if s.opts.Name == "" {
+		s.opts.Name = "http " + s.addr()
+	}
+
+	if srv.BaseContext == nil {
+		srv.BaseContext = func(_ net.Listener) context.Context {
+			return grace.shutdownCtx
+		}
+	}
+
+	connState := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		s.trackConn(conn, state)
+		if connState != nil {
+			connState(conn, state)
+		}
+	}
+
+	if opts.Phase != "" {
+		grace.Phase(opts.Phase, PhaseOptions{}).HookNamed(s.opts.Name, s.Shutdown)
+	}
+
+	grace.GoNamed(s.opts.Name, s.serve)
+	return s
+}
+
+// Server returns the underlying *http.Server.
+func (s *HTTPServer) Server() *http.Server {
+	return s.srv
+}
+
+// ForceClosed returns the number of connections that were closed
+// forcefully because they were still open when the deadline was reached.
+func (s *HTTPServer) ForceClosed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.forceClosed
+}
+
+// Shutdown gracefully shuts down the server. Once ctx is done, the connections
+// that are still open are closed forcefully and ForceClosed is updated. An
+// error is only returned when closing the server fails.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	s.grace.logger.Info("shutting down http server", "server", s.opts.Name)
+
+	err := s.srv.Shutdown(ctx)
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	s.mu.Lock()
+	n := len(s.conns)
+	s.forceClosed += n
+	s.mu.Unlock()
+
+	s.grace.logger.Warn("force closing http connections", "server", s.opts.Name, "connections", n)
+	return s.srv.Close()
+}
+
+// serve serves until the server is closed and shuts it down once shutdown starts,
+// unless it is shut down by a phase.
+func (s *HTTPServer) serve(ctx context.Context) error {
+	l := s.opts.Listener
+	if l == nil {
+		var err error
+		l, err = net.Listen("tcp", s.addr())
+		if err != nil {
+			return err
+		}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if s.opts.CertFile != "" || s.opts.KeyFile != "" || s.hasCertificates() {
+			errCh <- s.srv.ServeTLS(l, s.opts.CertFile, s.opts.KeyFile)
+		} else {
+			errCh <- s.srv.Serve(l)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+
+		return err
+	case <-ctx.Done():
+	}
+
+	var err error
+	if s.opts.Phase == "" {
+		s.grace.startShutdown()
+		err = s.Shutdown(s.grace.shutdownCtx)
+	}
+
+	if serveErr := <-errCh; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+
+	return err
+}
+
+func (s *HTTPServer) addr() string {
+	if s.opts.Listener != nil {
+		return s.opts.Listener.Addr().String()
+	}
+
+	if s.srv.Addr != "" {
+		return s.srv.Addr
+	}
+
+	if s.opts.CertFile != "" || s.hasCertificates() {
+		return ":https"
+	}
+
+	return ":http"
+}
+
+func (s *HTTPServer) hasCertificates() bool {
+	c := s.srv.TLSConfig
+	return c != nil && (len(c.Certificates) != 0 || c.GetCertificate != nil || c.GetConfigForClient != nil)
+}
+
+func (s *HTTPServer) trackConn(conn net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch state {
+	case http.StateNew:
+		s.conns[conn] = struct{}{}
+	case http.StateClosed, http.StateHijacked:
+		delete(s.conns, conn)
+	}
+}
@@ -0,0 +1,182 @@
+package gograce
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return l
+}
+
+func TestHTTPServer(t *testing.T) {
+	t.Run("graceful shutdown", func(t *testing.T) {
+		var (
+			l           = listen(t)
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger()})
+			started     = make(chan struct{})
+		)
+
+		s := grace.HTTPServer(&http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(50 * time.Millisecond)
+				_, _ = w.Write([]byte("done"))
+			}),
+		}, HTTPServerOptions{Listener: l})
+
+		require.Eventually(t, func() bool {
+			return len(grace.Running()) == 1 && grace.Running()[0] == "http "+l.Addr().String()
+		}, time.Second, time.Millisecond)
+
+		respCh := make(chan string)
+		go func() {
+			resp, err := http.Get("http://" + l.Addr().String())
+			if err != nil {
+				respCh <- err.Error()
+				return
+			}
+
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			respCh <- string(body)
+		}()
+
+		<-started
+		cancel()
+
+		require.NoError(t, grace.Wait())
+		require.Equal(t, "done", <-respCh)
+		require.Equal(t, 0, s.ForceClosed())
+
+		// the server does not accept connections anymore
+		_, err := net.Dial("tcp", l.Addr().String())
+		require.Error(t, err)
+	})
+
+	t.Run("force close", func(t *testing.T) {
+		var (
+			l           = listen(t)
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), Timeout: 100 * time.Millisecond})
+			started     = make(chan struct{})
+			release     = make(chan struct{})
+			reqCtxDone  = make(chan struct{})
+		)
+
+		defer close(release)
+
+		grace.th.timeoutFunc = func() {}
+
+		s := grace.HTTPServer(&http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-r.Context().Done()
+				close(reqCtxDone)
+				<-release
+			}),
+		}, HTTPServerOptions{Listener: l})
+
+		errCh := make(chan error)
+		go func() {
+			_, err := http.Get("http://" + l.Addr().String())
+			errCh <- err
+		}()
+
+		<-started
+		start := time.Now()
+		cancel()
+
+		require.NoError(t, grace.Wait())
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		require.Equal(t, 1, s.ForceClosed())
+		require.Error(t, <-errCh)
+
+		// requests are canceled once the timeout is reached
+		<-reqCtxDone
+	})
+
+	t.Run("phase", func(t *testing.T) {
+		var (
+			l           = listen(t)
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger()})
+			order       = make(chan string, 2)
+		)
+
+		grace.Phase("flush", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			order <- "flush"
+			return nil
+		})
+
+		var idle atomic.Bool
+		grace.HTTPServer(&http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			ConnState: func(conn net.Conn, state http.ConnState) {
+				if state == http.StateIdle {
+					idle.Store(true)
+				}
+			},
+		}, HTTPServerOptions{Listener: l, Phase: "drain", Name: "api"})
+
+		grace.Phase("drain", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			order <- "drain"
+			return nil
+		})
+
+		require.Eventually(t, func() bool {
+			return len(grace.Running()) == 1
+		}, time.Second, time.Millisecond)
+		require.Contains(t, grace.Running(), "api")
+
+		// ConnState of the server is still called
+		resp, err := http.Get("http://" + l.Addr().String())
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Eventually(t, idle.Load, time.Second, time.Millisecond)
+
+		cancel()
+		require.NoError(t, grace.Wait())
+
+		// the server is shut down in the drain phase, after flush
+		require.Equal(t, "flush", <-order)
+		require.Equal(t, "drain", <-order)
+
+		_, err = net.Dial("tcp", l.Addr().String())
+		require.Error(t, err)
+
+		r := grace.report(nil, false)
+		var names []string
+		for _, task := range r.Tasks {
+			names = append(names, task.Name)
+		}
+
+		require.Contains(t, names, "drain/api")
+	})
+
+	t.Run("listen error", func(t *testing.T) {
+		var (
+			l     = listen(t)
+			grace = NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		)
+
+		defer l.Close()
+
+		// the address is already in use
+		s := grace.HTTPServer(&http.Server{Addr: l.Addr().String()}, HTTPServerOptions{})
+		require.Equal(t, "http "+l.Addr().String(), s.opts.Name)
+		require.Error(t, grace.Wait())
+	})
+}
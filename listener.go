@@ -0,0 +1,169 @@
+package gograce
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// ListenerOptions
+type ListenerOptions struct {
+	// Name is used as the name of the task draining the listener.
+	// an empty value indicates "listener " followed by the listen address.
+	Name string
+
+	// Phase is the name of the shutdown phase the listener is drained in.
+	// an empty value indicates the listener is drained as soon as shutdown starts.
+	Phase string
+}
+
+// Listener is a net.Listener that keeps track of the connections it accepts. When it
+// is drained it stops accepting connections, waits for the accepted connections to be
+// closed and closes the ones that are still open once the deadline is reached. This is
+// what http.Server.Shutdown does for HTTP, for any protocol.
+//
+// Once the listener is closed Accept returns an error wrapping net.ErrClosed.
+type Listener struct {
+	net.Listener
+
+	opts  ListenerOptions
+	grace *Graceful
+
+	mu          sync.Mutex
+	conns       map[*trackedConn]struct{}
+	forceClosed int
+
+	// closed is signaled whenever a connection is closed.
+	closed chan struct{}
+}
+
+// Listener wraps l so it is drained on shutdown. Connections should be
+// accepted from the returned Listener instead of l.
+func (grace *Graceful) Listener(l net.Listener, opts ListenerOptions) *Listener {
+	if opts.Name == "" {
+		opts.Name = "listener " + l.Addr().String()
+	}
+
+	tl := &Listener{
+		Listener: l,
+		opts:     opts,
+		grace:    grace,
+		conns:    make(map[*trackedConn]struct{}),
+		closed:   make(chan struct{}, 1),
+	}
+
+	if opts.Phase != "" {
+		grace.Phase(opts.Phase, PhaseOptions{}).HookNamed(opts.Name, tl.Drain)
+		return tl
+	}
+
+	// like OnShutdown, so it does not take a slot of Options.MaxGoRoutines
+	t := grace.newTask(opts.Name)
+	grace.g.Go(func() error {
+		<-grace.ctx.Done()
+		grace.startShutdown()
+		return grace.runTask(t, func() error {
+			return tl.Drain(grace.shutdownCtx)
+		})
+	})
+
+	return tl
+}
+
+// Accept waits for and returns the next connection.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &trackedConn{Conn: conn, l: l}
+
+	l.mu.Lock()
+	l.conns[c] = struct{}{}
+	l.mu.Unlock()
+
+	return c, nil
+}
+
+// Active returns the number of accepted connections that are still open.
+func (l *Listener) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.conns)
+}
+
+// ForceClosed returns the number of connections that were closed
+// forcefully because they were still open when the deadline was reached.
+func (l *Listener) ForceClosed() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.forceClosed
+}
+
+// Drain closes the listener and waits for the accepted connections to be closed.
+// Once ctx is done, the connections that are still open are closed forcefully and
+// ForceClosed is updated. It always returns nil so it can be used as a HookFunc.
+func (l *Listener) Drain(ctx context.Context) error {
+	l.grace.logger.Info("draining listener", "listener", l.opts.Name, "connections", l.Active())
+
+	// the error is ignored since the listener may have been closed by the caller
+	_ = l.Listener.Close()
+
+	for {
+		if l.Active() == 0 {
+			return nil
+		}
+
+		select {
+		case <-l.closed:
+		case <-ctx.Done():
+			l.forceClose()
+			return nil
+		}
+	}
+}
+
+func (l *Listener) forceClose() {
+	l.mu.Lock()
+	conns := make([]*trackedConn, 0, len(l.conns))
+	for c := range l.conns {
+		conns = append(conns, c)
+	}
+	l.forceClosed += len(conns)
+	l.mu.Unlock()
+
+	l.grace.logger.Warn("force closing connections", "listener", l.opts.Name, "connections", len(conns))
+
+	for _, c := range conns {
+		_ = c.Close()
+	}
+}
+
+func (l *Listener) untrack(c *trackedConn) {
+	l.mu.Lock()
+	delete(l.conns, c)
+	l.mu.Unlock()
+
+	select {
+	case l.closed <- struct{}{}:
+	default:
+	}
+}
+
+// trackedConn removes itself from the listener when it is closed.
+type trackedConn struct {
+	net.Conn
+
+	l         *Listener
+	closeOnce sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		c.l.untrack(c)
+	})
+
+	return err
+}
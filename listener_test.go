@@ -0,0 +1,151 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// echo accepts connections from l and echoes what it reads until the client closes the connection.
+func echo(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			_, _ = io.Copy(conn, conn)
+		}()
+	}
+}
+
+func dial(t *testing.T, l net.Listener) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+
+	return conn
+}
+
+func TestListener(t *testing.T) {
+	t.Run("drain", func(t *testing.T) {
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), MaxGoRoutines: 1})
+			l           = grace.Listener(listen(t), ListenerOptions{})
+		)
+
+		require.Equal(t, "listener "+l.Addr().String(), l.opts.Name)
+
+		// draining does not take the only slot of MaxGoRoutines, echo gets it
+		grace.GoWithContext(func(ctx context.Context) error {
+			return echo(l)
+		})
+
+		conn := dial(t, l)
+		require.Equal(t, 1, l.Active())
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- grace.Wait()
+		}()
+
+		cancel()
+
+		// new connections are not accepted anymore
+		require.Eventually(t, func() bool {
+			_, err := net.Dial("tcp", l.Addr().String())
+			return err != nil
+		}, time.Second, time.Millisecond)
+
+		// the accepted connection still works
+		_, err := conn.Write([]byte("pong"))
+		require.NoError(t, err)
+
+		select {
+		case err := <-errCh:
+			t.Fatalf("Wait returned before the connection was closed: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, conn.Close())
+		require.NoError(t, <-errCh)
+		require.Equal(t, 0, l.Active())
+		require.Equal(t, 0, l.ForceClosed())
+	})
+
+	t.Run("force close", func(t *testing.T) {
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), Timeout: 100 * time.Millisecond})
+			l           = grace.Listener(listen(t), ListenerOptions{Name: "echo"})
+		)
+
+		grace.th.timeoutFunc = func() {}
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			return echo(l)
+		})
+
+		conn := dial(t, l)
+		defer conn.Close()
+
+		start := time.Now()
+		cancel()
+
+		require.NoError(t, grace.Wait())
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		require.Equal(t, 1, l.ForceClosed())
+		require.Equal(t, 0, l.Active())
+
+		// the server side of the connection is closed
+		_, err := conn.Read(make([]byte, 1))
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("phase", func(t *testing.T) {
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger()})
+			conn        net.Conn
+		)
+
+		// the connection is only closed in a later phase, so
+		// it is still open once the drain phase budget runs out
+		grace.Phase("drain", PhaseOptions{Timeout: 50 * time.Millisecond})
+		grace.Phase("close", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			return conn.Close()
+		})
+
+		l := grace.Listener(listen(t), ListenerOptions{Name: "echo", Phase: "drain"})
+		grace.GoWithContext(func(ctx context.Context) error {
+			return echo(l)
+		})
+
+		conn = dial(t, l)
+
+		cancel()
+		require.NoError(t, grace.Wait())
+		require.Equal(t, 1, l.ForceClosed())
+	})
+}
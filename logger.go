@@ -0,0 +1,50 @@
+package gograce
+
+import (
+	"log/slog"
+)
+
+// Logger is used to log what happens during startup and shutdown. Events are logged
+// with a message and key-value pairs like "signal", "phase", "task" and "elapsed".
+// *slog.Logger implements Logger.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NewSlogLogger returns a Logger that logs to l. If l is nil, slog.Default()
+// is used at the time of logging. This is the default Logger.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+// NopLogger returns a Logger that discards everything, useful for tests and CLIs.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) logger() *slog.Logger {
+	if s.l == nil {
+		return slog.Default()
+	}
+
+	return s.l
+}
+
+func (s slogLogger) Debug(msg string, args ...any) { s.logger().Debug(msg, args...) }
+func (s slogLogger) Info(msg string, args ...any)  { s.logger().Info(msg, args...) }
+func (s slogLogger) Warn(msg string, args ...any)  { s.logger().Warn(msg, args...) }
+func (s slogLogger) Error(msg string, args ...any) { s.logger().Error(msg, args...) }
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
@@ -0,0 +1,82 @@
+package gograce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Run("with logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+		logger.Info("running shutdown phase", "phase", "drain")
+		require.Contains(t, buf.String(), `level=INFO msg="running shutdown phase" phase=drain`)
+	})
+
+	t.Run("default logger", func(t *testing.T) {
+		var (
+			buf      bytes.Buffer
+			previous = slog.Default()
+		)
+
+		defer slog.SetDefault(previous)
+
+		// the default logger is used at the time of logging
+		logger := NewSlogLogger(nil)
+		slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+		logger.Warn("tasks still running", "tasks", []string{"worker"})
+		require.Contains(t, buf.String(), `level=WARN msg="tasks still running" tasks=[worker]`)
+	})
+}
+
+func TestSignalHandlerLogger(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		logger = NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	)
+
+	sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
+		Logger: logger,
+	})
+
+	sh.sigChan <- syscall.SIGTERM
+	<-ctx.Done()
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "INFO", entry["level"])
+	require.Equal(t, "received signal, gracefully quitting", entry["msg"])
+	require.Equal(t, "terminated", entry["signal"])
+}
+
+func TestNopLogger(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		previous = slog.Default()
+	)
+
+	defer slog.SetDefault(previous)
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	grace := NewGracefulWithContext(context.Background(), Options{
+		Logger: NopLogger(),
+	})
+
+	grace.GoNamed("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	grace.sh.sigChan <- syscall.SIGINT
+
+	require.NoError(t, grace.Wait())
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,260 @@
+// Package metrics exposes graceful shutdown metrics in the OpenMetrics text format
+// without depending on a metrics client library.
+//
+// Metrics implements gograce.Observer, so it can instrument Graceful through
+// gograce.Options.Observers, or a SignalHandler and TimeoutHandler through the
+// Observer field of their options.
+//
+//	m := metrics.New(metrics.Options{})
+//	grace := gograce.NewGraceful(gograce.Options{
+//		Observers: []gograce.Observer{m},
+//	})
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/itzloop/gograce"
+)
+
+// ContentType is the content type of the OpenMetrics text format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// DefaultBuckets are the default buckets of the shutdown duration histogram, in seconds.
+var DefaultBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 15, 30, 60, 120}
+
+// Options
+type Options struct {
+	// Namespace is prepended to the name of every metric.
+	// a zero-value indicates "gograce".
+	Namespace string
+
+	// Buckets are the upper bounds of the shutdown duration histogram, in seconds.
+	// a nil value indicates DefaultBuckets.
+	Buckets []float64
+}
+
+// Metrics collects graceful shutdown metrics from lifecycle events. It is safe
+// to use from multiple go-routines.
+type Metrics struct {
+	namespace string
+	buckets   []float64
+
+	mu sync.Mutex
+
+	// shutdown duration histogram, counts[i] is the number of observations
+	// less than or equal to buckets[i].
+	counts []uint64
+	sum    float64
+	count  uint64
+
+	signals          map[string]uint64
+	forceQuits       uint64
+	timeouts         uint64
+	runningAtTimeout int
+
+	tasks map[string]*taskMetrics
+}
+
+type taskMetrics struct {
+	sum    float64
+	count  uint64
+	errors uint64
+}
+
+// New creates Metrics based on opts.
+func New(opts Options) *Metrics {
+	if opts.Namespace == "" {
+		opts.Namespace = "gograce"
+	}
+
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultBuckets
+	}
+
+	buckets := make([]float64, len(opts.Buckets))
+	copy(buckets, opts.Buckets)
+	sort.Float64s(buckets)
+
+	return &Metrics{
+		namespace: opts.Namespace,
+		buckets:   buckets,
+		counts:    make([]uint64, len(buckets)),
+		signals:   make(map[string]uint64),
+		tasks:     make(map[string]*taskMetrics),
+	}
+}
+
+// Observe implements gograce.Observer.
+func (m *Metrics) Observe(e gograce.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch e.Kind {
+	case gograce.EventSignal:
+		if e.Signal != nil {
+			m.signals[e.Signal.String()]++
+		}
+	case gograce.EventTaskDone:
+		t, ok := m.tasks[e.Task]
+		if !ok {
+			t = &taskMetrics{}
+			m.tasks[e.Task] = t
+		}
+
+		t.sum += e.Duration.Seconds()
+		t.count++
+		if e.Err != nil {
+			t.errors++
+		}
+	case gograce.EventForceQuit:
+		m.forceQuits++
+	case gograce.EventTimeout:
+		m.timeouts++
+		m.runningAtTimeout = len(e.Running)
+	case gograce.EventShutdownCompleted:
+		seconds := e.Duration.Seconds()
+		for i, bound := range m.buckets {
+			if seconds <= bound {
+				m.counts[i]++
+			}
+		}
+
+		m.sum += seconds
+		m.count++
+	}
+}
+
+// ServeHTTP writes the metrics in the OpenMetrics text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the OpenMetrics text format to w.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	name := m.name("shutdown_duration_seconds")
+	cw.family(name, "histogram", "Time it took from the start of shutdown until Wait returned.")
+	for i, bound := range m.buckets {
+		cw.sample(name+"_bucket", label("le", formatFloat(bound)), m.counts[i])
+	}
+	cw.sample(name+"_bucket", label("le", "+Inf"), m.count)
+	cw.sample(name+"_sum", "", m.sum)
+	cw.sample(name+"_count", "", m.count)
+
+	name = m.name("signals")
+	cw.family(name, "counter", "Signals that started graceful shutdown.")
+	for _, sig := range sortedKeys(m.signals) {
+		cw.sample(name+"_total", label("signal", sig), m.signals[sig])
+	}
+
+	name = m.name("force_quits")
+	cw.family(name, "counter", "Number of times the application was forcefully quit.")
+	cw.sample(name+"_total", "", m.forceQuits)
+
+	name = m.name("timeouts")
+	cw.family(name, "counter", "Number of times the shutdown timeout was reached.")
+	cw.sample(name+"_total", "", m.timeouts)
+
+	name = m.name("tasks_running_at_timeout")
+	cw.family(name, "gauge", "Number of tasks that were still running when the shutdown timeout was reached.")
+	cw.sample(name, "", m.runningAtTimeout)
+
+	tasks := sortedKeys(m.tasks)
+
+	name = m.name("task_duration_seconds")
+	cw.family(name, "summary", "Time it took for a task to return.")
+	for _, task := range tasks {
+		cw.sample(name+"_sum", label("task", task), m.tasks[task].sum)
+		cw.sample(name+"_count", label("task", task), m.tasks[task].count)
+	}
+
+	name = m.name("task_errors")
+	cw.family(name, "counter", "Errors returned by tasks.")
+	for _, task := range tasks {
+		cw.sample(name+"_total", label("task", task), m.tasks[task].errors)
+	}
+
+	cw.printf("# EOF\n")
+
+	return cw.flush()
+}
+
+func (m *Metrics) name(name string) string {
+	return m.namespace + "_" + name
+}
+
+// countingWriter keeps the first error and the number of bytes written so
+// WriteTo does not have to check every write.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) printf(format string, args ...any) {
+	if cw.err != nil {
+		return
+	}
+
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) family(name, typ, help string) {
+	cw.printf("# TYPE %s %s\n# HELP %s %s\n", name, typ, name, help)
+}
+
+func (cw *countingWriter) sample(name, labels string, value any) {
+	if f, ok := value.(float64); ok {
+		value = formatFloat(f)
+	}
+
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+
+	cw.printf("%s%s %v\n", name, labels, value)
+}
+
+func (cw *countingWriter) flush() (int64, error) {
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+
+	return cw.n, cw.w.Flush()
+}
+
+// label formats a label pair, escaping the value as required by OpenMetrics.
+func label(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return fmt.Sprintf("%s=\"%s\"", name, value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/itzloop/gograce"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New(Options{Buckets: []float64{5, 1}})
+
+	m.Observe(gograce.Event{Kind: gograce.EventSignal, Signal: syscall.SIGTERM})
+	m.Observe(gograce.Event{Kind: gograce.EventTaskDone, Task: "server", Duration: 500 * time.Millisecond})
+	m.Observe(gograce.Event{Kind: gograce.EventTaskDone, Task: `flush/"db"`, Duration: time.Second, Err: errors.New("failed")})
+	m.Observe(gograce.Event{Kind: gograce.EventTaskDone, Task: "server", Duration: 250 * time.Millisecond})
+	m.Observe(gograce.Event{Kind: gograce.EventForceQuit, Signal: syscall.SIGINT})
+	m.Observe(gograce.Event{Kind: gograce.EventTimeout, Running: []string{"worker", "flush"}})
+	m.Observe(gograce.Event{Kind: gograce.EventShutdownCompleted, Duration: 2 * time.Second})
+
+	var buf strings.Builder
+	n, err := m.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+	require.Equal(t, `# TYPE gograce_shutdown_duration_seconds histogram
+# HELP gograce_shutdown_duration_seconds Time it took from the start of shutdown until Wait returned.
+gograce_shutdown_duration_seconds_bucket{le="1"} 0
+gograce_shutdown_duration_seconds_bucket{le="5"} 1
+gograce_shutdown_duration_seconds_bucket{le="+Inf"} 1
+gograce_shutdown_duration_seconds_sum 2
+gograce_shutdown_duration_seconds_count 1
+# TYPE gograce_signals counter
+# HELP gograce_signals Signals that started graceful shutdown.
+gograce_signals_total{signal="terminated"} 1
+# TYPE gograce_force_quits counter
+# HELP gograce_force_quits Number of times the application was forcefully quit.
+gograce_force_quits_total 1
+# TYPE gograce_timeouts counter
+# HELP gograce_timeouts Number of times the shutdown timeout was reached.
+gograce_timeouts_total 1
+# TYPE gograce_tasks_running_at_timeout gauge
+# HELP gograce_tasks_running_at_timeout Number of tasks that were still running when the shutdown timeout was reached.
+gograce_tasks_running_at_timeout 2
+# TYPE gograce_task_duration_seconds summary
+# HELP gograce_task_duration_seconds Time it took for a task to return.
+gograce_task_duration_seconds_sum{task="flush/\"db\""} 1
+gograce_task_duration_seconds_count{task="flush/\"db\""} 1
+gograce_task_duration_seconds_sum{task="server"} 0.75
+gograce_task_duration_seconds_count{task="server"} 2
+# TYPE gograce_task_errors counter
+# HELP gograce_task_errors Errors returned by tasks.
+gograce_task_errors_total{task="flush/\"db\""} 1
+gograce_task_errors_total{task="server"} 0
+# EOF
+`, buf.String())
+}
+
+func TestMetricsHandler(t *testing.T) {
+	var (
+		m     = New(Options{Namespace: "app"})
+		grace = gograce.NewGracefulWithContext(context.Background(), gograce.Options{
+			Observers: []gograce.Observer{m},
+			Logger:    gograce.NopLogger(),
+		})
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	grace.GoNamed("server", func(context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	cancel()
+	require.NoError(t, grace.Wait())
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	require.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	require.Contains(t, string(body), "app_shutdown_duration_seconds_count 1\n")
+	require.Contains(t, string(body), "app_task_duration_seconds_count{task=\"server\"} 1\n")
+	require.True(t, strings.HasSuffix(string(body), "# EOF\n"))
+}
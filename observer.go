@@ -0,0 +1,155 @@
+package gograce
+
+import (
+	"os"
+	"time"
+)
+
+// EventKind is the kind of a lifecycle Event.
+type EventKind int
+
+const (
+	// EventSignal is emitted when a signal starting graceful shutdown is received.
+	EventSignal EventKind = iota + 1
+
+	// EventShutdownStarted is emitted when the context of Graceful is canceled.
+	EventShutdownStarted
+
+	// EventTaskDone is emitted when a go-routine or hook returns.
+	EventTaskDone
+
+	// EventForceQuit is emitted when the application is forcefully quit.
+	EventForceQuit
+
+	// EventTimeout is emitted when the shutdown timeout is reached.
+	EventTimeout
+
+	// EventShutdownCompleted is emitted when Wait returns.
+	EventShutdownCompleted
+
+	// EventReload is emitted when the registered reloaders have been reloaded.
+	EventReload
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSignal:
+		return "signal"
+	case EventShutdownStarted:
+		return "shutdown_started"
+	case EventTaskDone:
+		return "task_done"
+	case EventForceQuit:
+		return "force_quit"
+	case EventTimeout:
+		return "timeout"
+	case EventShutdownCompleted:
+		return "shutdown_completed"
+	case EventReload:
+		return "reload"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes something that happened during the lifecycle of Graceful.
+// Fields that are not relevant to Kind are left as zero-values.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+
+	// Signal is the received signal for EventSignal and EventForceQuit.
+	Signal os.Signal
+
+	// Task is the name of the task for EventTaskDone.
+	Task string
+
+	// Err is the error returned by the task for EventTaskDone, the error returned
+	// by Wait for EventShutdownCompleted and the error returned by Reload for EventReload.
+	Err error
+
+	// Duration is how long the task ran for EventTaskDone, how long shutdown took
+	// for EventShutdownCompleted, the timeout for EventTimeout and how long
+	// reloading took for EventReload.
+	Duration time.Duration
+
+	// Running are the names of the tasks that are still running for EventShutdownStarted,
+	// EventForceQuit and EventTimeout.
+	Running []string
+}
+
+// An Observer is notified about lifecycle events. Observe is called synchronously
+// from the go-routine the event happens on, so it should not block.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc is an adapter to allow the use of ordinary functions as Observer.
+type ObserverFunc func(e Event)
+
+// Observe calls f(e).
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// observe passes e to Options.Observers and makes sure EventShutdownStarted is
+// emitted in order. The context is canceled right after a signal is received, so
+// shutdown is started here instead of waiting for the context to be done.
+func (grace *Graceful) observe(e Event) {
+	if e.Kind == EventForceQuit {
+		grace.mu.Lock()
+		grace.forcedBy = e.Signal
+		grace.mu.Unlock()
+	}
+
+	if e.Kind == EventForceQuit || e.Kind == EventTimeout {
+		grace.startShutdown()
+	}
+
+	// the timeout is armed once the context is canceled, right after EventSignal
+	if e.Kind == EventSignal && grace.th != nil {
+		if action, ok := grace.opts.SignalActions[e.Signal]; ok && action.Timeout != 0 {
+			grace.th.setTimeout(action.Timeout)
+		}
+	}
+
+	grace.notify(e)
+
+	if e.Kind == EventSignal {
+		grace.startShutdown()
+	}
+}
+
+// notify fills in the event with what only Graceful knows about
+// and passes it to Options.Observers.
+func (grace *Graceful) notify(e Event) {
+	if len(grace.opts.Observers) == 0 {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = grace.clock.Now()
+	}
+
+	if e.Running == nil && (e.Kind == EventForceQuit || e.Kind == EventTimeout || e.Kind == EventShutdownStarted) {
+		e.Running = grace.Running()
+	}
+
+	for _, o := range grace.opts.Observers {
+		o.Observe(e)
+	}
+}
+
+// startShutdown records when shutdown started and emits EventShutdownStarted.
+// Only the first call has an effect. It is called before running anything that
+// belongs to shutdown, so EventShutdownStarted is emitted before their events.
+func (grace *Graceful) startShutdown() {
+	grace.shutdownOnce.Do(func() {
+		grace.mu.Lock()
+		grace.shutdownStart = grace.clock.Now()
+		grace.mu.Unlock()
+
+		grace.sdStopping()
+		grace.observe(Event{Kind: EventShutdownStarted})
+	})
+}
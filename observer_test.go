@@ -0,0 +1,147 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testObserver struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (o *testObserver) Observe(e Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, e)
+}
+
+func (o *testObserver) kinds() []EventKind {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	kinds := make([]EventKind, 0, len(o.events))
+	for _, e := range o.events {
+		kinds = append(kinds, e.Kind)
+	}
+
+	return kinds
+}
+
+func (o *testObserver) event(kind EventKind) Event {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, e := range o.events {
+		if e.Kind == kind {
+			return e
+		}
+	}
+
+	return Event{}
+}
+
+func TestObserver(t *testing.T) {
+	t.Run("graceful shutdown", func(t *testing.T) {
+		var (
+			o         = &testObserver{}
+			errBackup = errors.New("backup failed")
+			grace     = NewGracefulWithContext(context.Background(), Options{
+				Observers: []Observer{o},
+			})
+		)
+
+		grace.GoNamed("server", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		grace.Phase("flush", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return errBackup
+		})
+
+		grace.sh.sigChan <- syscall.SIGTERM
+
+		require.ErrorIs(t, grace.Wait(), errBackup)
+		require.Equal(t, []EventKind{
+			EventSignal,
+			EventShutdownStarted,
+			EventTaskDone,
+			EventTaskDone,
+			EventShutdownCompleted,
+		}, o.kinds())
+
+		require.Equal(t, syscall.SIGTERM, o.event(EventSignal).Signal)
+
+		o.mu.Lock()
+		server, flush := o.events[2], o.events[3]
+		o.mu.Unlock()
+
+		require.Equal(t, "server", server.Task)
+		require.NoError(t, server.Err)
+		require.Equal(t, "flush/github.com/itzloop/gograce.TestObserver.func1.2", flush.Task)
+		require.ErrorIs(t, flush.Err, errBackup)
+		require.GreaterOrEqual(t, flush.Duration, 10*time.Millisecond)
+
+		completed := o.event(EventShutdownCompleted)
+		require.ErrorIs(t, completed.Err, errBackup)
+		require.GreaterOrEqual(t, completed.Duration, 10*time.Millisecond)
+	})
+
+	t.Run("force quit and timeout", func(t *testing.T) {
+		var (
+			o     = &testObserver{}
+			wg    = sync.WaitGroup{}
+			block = make(chan struct{})
+		)
+
+		defer close(block)
+
+		wg.Add(2)
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Timeout:     50 * time.Millisecond,
+			Observers:   []Observer{o},
+			ForceFunc:   wg.Done,
+			TimeoutFunc: wg.Done,
+		})
+
+		started := make(chan struct{})
+		grace.GoNamed("worker", func(ctx context.Context) error {
+			close(started)
+			<-block
+			return nil
+		})
+
+		// the worker is only reported as running once it has started
+		<-started
+		grace.sh.sigChan <- syscall.SIGINT
+		grace.sh.sigChan <- syscall.SIGINT
+		wg.Wait()
+
+		require.Equal(t, []EventKind{
+			EventSignal,
+			EventShutdownStarted,
+			EventForceQuit,
+			EventTimeout,
+		}, o.kinds())
+
+		require.Equal(t, []string{"worker"}, o.event(EventShutdownStarted).Running)
+		require.Equal(t, []string{"worker"}, o.event(EventForceQuit).Running)
+		require.Equal(t, syscall.SIGINT, o.event(EventForceQuit).Signal)
+		require.Equal(t, []string{"worker"}, o.event(EventTimeout).Running)
+		require.Equal(t, 50*time.Millisecond, o.event(EventTimeout).Duration)
+	})
+}
+
+func TestEventKindString(t *testing.T) {
+	require.Equal(t, "signal", EventSignal.String())
+	require.Equal(t, "shutdown_completed", EventShutdownCompleted.String())
+	require.Equal(t, "unknown", EventKind(0).String())
+}
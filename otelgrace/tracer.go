@@ -0,0 +1,217 @@
+// Package otelgrace traces the graceful shutdown sequence with OpenTelemetry.
+//
+// It lives in its own module so gograce itself does not depend on OpenTelemetry.
+// Tracer implements gograce.Observer and creates a root span when shutdown starts
+// and a child span for every task that runs during shutdown.
+//
+//	grace := gograce.NewGraceful(gograce.Options{
+//		Observers: []gograce.Observer{otelgrace.New(otelgrace.Options{})},
+//	})
+package otelgrace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/itzloop/gograce"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ScopeName is the instrumentation scope name of the tracer.
+	ScopeName = "github.com/itzloop/gograce/otelgrace"
+
+	// ShutdownSpanName is the name of the root span.
+	ShutdownSpanName = "gograce.shutdown"
+
+	// SignalKey is the attribute with the signal that started shutdown.
+	SignalKey = attribute.Key("gograce.signal")
+
+	// TaskKey is the attribute with the name of a task.
+	TaskKey = attribute.Key("gograce.task")
+
+	// RunningKey is the attribute with the names of the tasks that are still running.
+	RunningKey = attribute.Key("gograce.running")
+)
+
+// Options
+type Options struct {
+	// TracerProvider is used to create the tracer.
+	// a nil value indicates otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+
+	// Context is the parent of the root span.
+	// a nil value indicates context.Background().
+	Context context.Context
+}
+
+// Tracer is a gograce.Observer that creates spans for the shutdown sequence. The root
+// span starts when a signal is received, or when shutdown starts for other reasons,
+// and every task that is running or starts during shutdown gets a child span which
+// ends when the task returns, with the error of the task as status.
+type Tracer struct {
+	tracer trace.Tracer
+	parent context.Context
+
+	mu        sync.Mutex
+	ctx       context.Context
+	root      trace.Span
+	rootStart time.Time
+
+	// tasks are the spans of running tasks by name, oldest first. Names are
+	// not unique, e.g. the same hook can be registered twice.
+	tasks map[string][]trace.Span
+}
+
+// New creates a Tracer based on opts.
+func New(opts Options) *Tracer {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+
+	return &Tracer{
+		tracer: opts.TracerProvider.Tracer(ScopeName),
+		parent: opts.Context,
+		tasks:  make(map[string][]trace.Span),
+	}
+}
+
+// Observe implements gograce.Observer.
+func (t *Tracer) Observe(e gograce.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch e.Kind {
+	case gograce.EventSignal:
+		t.startRoot(e.Time)
+		if e.Signal != nil {
+			t.root.SetAttributes(SignalKey.String(e.Signal.String()))
+		}
+	case gograce.EventShutdownStarted:
+		t.startRoot(e.Time)
+		for _, name := range e.Running {
+			t.startTask(name, e.Time)
+		}
+	case gograce.EventTaskDone:
+		// tasks that return before shutdown are not traced
+		if t.root == nil {
+			return
+		}
+
+		if len(t.tasks[e.Task]) == 0 {
+			// the task started during shutdown, e.g. a hook
+			start := e.Time.Add(-e.Duration)
+			if start.Before(t.rootStart) {
+				start = t.rootStart
+			}
+
+			t.startTask(e.Task, start)
+		}
+
+		spans := t.tasks[e.Task]
+		endSpan(spans[0], e.Err, e.Time)
+		if len(spans) == 1 {
+			delete(t.tasks, e.Task)
+		} else {
+			t.tasks[e.Task] = spans[1:]
+		}
+	case gograce.EventForceQuit:
+		if t.root == nil {
+			return
+		}
+
+		var attrs []attribute.KeyValue
+		if e.Signal != nil {
+			attrs = append(attrs, SignalKey.String(e.Signal.String()))
+		}
+
+		t.root.AddEvent("force quit", trace.WithTimestamp(e.Time), trace.WithAttributes(attrs...))
+	case gograce.EventTimeout:
+		if t.root == nil {
+			return
+		}
+
+		t.root.AddEvent("timeout", trace.WithTimestamp(e.Time), trace.WithAttributes(RunningKey.StringSlice(e.Running)))
+
+		// the application is about to quit, end every span so they can be exported
+		for name, spans := range t.tasks {
+			for _, span := range spans {
+				span.SetStatus(codes.Error, "still running when the shutdown timeout was reached")
+				span.End(trace.WithTimestamp(e.Time))
+			}
+
+			delete(t.tasks, name)
+		}
+
+		t.root.SetStatus(codes.Error, "shutdown timeout reached")
+		t.endRoot(e.Time)
+	case gograce.EventShutdownCompleted:
+		if t.root == nil {
+			return
+		}
+
+		for name, spans := range t.tasks {
+			for _, span := range spans {
+				span.End(trace.WithTimestamp(e.Time))
+			}
+
+			delete(t.tasks, name)
+		}
+
+		if e.Err != nil {
+			t.root.RecordError(e.Err, trace.WithTimestamp(e.Time))
+			t.root.SetStatus(codes.Error, e.Err.Error())
+		}
+
+		t.endRoot(e.Time)
+	}
+}
+
+// startRoot starts the root span if it has not been started yet.
+func (t *Tracer) startRoot(start time.Time) {
+	if t.root != nil {
+		return
+	}
+
+	t.ctx, t.root = t.tracer.Start(t.parent, ShutdownSpanName, trace.WithTimestamp(start))
+	t.rootStart = start
+}
+
+func (t *Tracer) endRoot(end time.Time) {
+	t.root.End(trace.WithTimestamp(end))
+	t.root = nil
+	t.ctx = nil
+}
+
+// startTask starts a child span of the root span for the task with the given name.
+func (t *Tracer) startTask(name string, start time.Time) {
+	_, span := t.tracer.Start(t.ctx, name,
+		trace.WithTimestamp(start),
+		trace.WithAttributes(TaskKey.String(name)),
+	)
+
+	t.tasks[name] = append(t.tasks[name], span)
+}
+
+func endSpan(span trace.Span, err error, end time.Time) {
+	if err != nil {
+		span.RecordError(err, trace.WithTimestamp(end))
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
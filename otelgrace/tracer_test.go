@@ -0,0 +1,159 @@
+package otelgrace
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/itzloop/gograce"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestTracer() (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	return New(Options{TracerProvider: tp}), exporter
+}
+
+func spansByName(spans tracetest.SpanStubs) map[string]tracetest.SpanStub {
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	return byName
+}
+
+func TestTracer(t *testing.T) {
+	var (
+		tracer, exporter = newTestTracer()
+		errClose         = errors.New("close failed")
+		ctx, cancel      = context.WithCancel(context.Background())
+		grace            = gograce.NewGracefulWithContext(ctx, gograce.Options{
+			Observers: []gograce.Observer{tracer},
+			Logger:    gograce.NopLogger(),
+		})
+	)
+
+	// returns before shutdown so it is not traced
+	grace.GoNamed("migrate", func(ctx context.Context) error {
+		return nil
+	})
+
+	grace.GoNamed("server", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	grace.Phase("close", gograce.PhaseOptions{}).Hook(func(ctx context.Context) error {
+		return errClose
+	})
+
+	require.Eventually(t, func() bool {
+		return len(grace.Running()) == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, grace.Wait(), errClose)
+
+	spans := spansByName(exporter.GetSpans())
+	require.Len(t, spans, 3)
+
+	root := spans[ShutdownSpanName]
+	require.Equal(t, codes.Error, root.Status.Code)
+	require.False(t, root.Parent.IsValid())
+
+	server := spans["server"]
+	require.Equal(t, root.SpanContext.SpanID(), server.Parent.SpanID())
+	require.Equal(t, codes.Ok, server.Status.Code)
+	require.GreaterOrEqual(t, server.EndTime.Sub(server.StartTime), 10*time.Millisecond)
+
+	var hook tracetest.SpanStub
+	for name, span := range spans {
+		if name != ShutdownSpanName && name != "server" {
+			hook = span
+		}
+	}
+
+	require.Equal(t, root.SpanContext.SpanID(), hook.Parent.SpanID())
+	require.Equal(t, codes.Error, hook.Status.Code)
+	require.Equal(t, errClose.Error(), hook.Status.Description)
+	require.False(t, hook.StartTime.Before(root.StartTime))
+}
+
+func TestTracerSignalAndTimeout(t *testing.T) {
+	tracer, exporter := newTestTracer()
+
+	// drive the tracer with the events Graceful would emit
+	start := time.Now()
+	tracer.Observe(gograce.Event{Kind: gograce.EventSignal, Time: start, Signal: syscall.SIGTERM})
+	tracer.Observe(gograce.Event{Kind: gograce.EventShutdownStarted, Time: start, Running: []string{"worker", "server"}})
+	tracer.Observe(gograce.Event{Kind: gograce.EventTaskDone, Time: start.Add(time.Second), Task: "server", Duration: time.Hour})
+
+	// only the server span has ended
+	require.Len(t, exporter.GetSpans(), 1)
+
+	tracer.Observe(gograce.Event{Kind: gograce.EventTimeout, Time: start.Add(2 * time.Second), Running: []string{"worker"}})
+
+	spans := spansByName(exporter.GetSpans())
+	require.Len(t, spans, 3)
+
+	root := spans[ShutdownSpanName]
+	require.Equal(t, codes.Error, root.Status.Code)
+	require.Equal(t, 2*time.Second, root.EndTime.Sub(root.StartTime))
+	require.Contains(t, root.Attributes, SignalKey.String("terminated"))
+	require.Len(t, root.Events, 1)
+	require.Equal(t, "timeout", root.Events[0].Name)
+
+	require.Equal(t, time.Second, spans["server"].EndTime.Sub(spans["server"].StartTime))
+	require.Equal(t, codes.Ok, spans["server"].Status.Code)
+
+	require.Equal(t, codes.Error, spans["worker"].Status.Code)
+	require.Equal(t, 2*time.Second, spans["worker"].EndTime.Sub(spans["worker"].StartTime))
+}
+
+func TestTracerSameName(t *testing.T) {
+	var (
+		tracer, exporter = newTestTracer()
+		ctx, cancel      = context.WithCancel(context.Background())
+		grace            = gograce.NewGracefulWithContext(ctx, gograce.Options{
+			Observers: []gograce.Observer{tracer},
+			Logger:    gograce.NopLogger(),
+		})
+		errWorker = errors.New("worker failed")
+	)
+
+	for _, err := range []error{nil, errWorker} {
+		err := err
+		grace.GoNamed("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return err
+		})
+	}
+
+	grace.Phase("close", gograce.PhaseOptions{}).HookNamed("flush", func(ctx context.Context) error { return nil })
+	grace.Phase("close", gograce.PhaseOptions{}).HookNamed("flush", func(ctx context.Context) error { return nil })
+
+	require.Eventually(t, func() bool {
+		return len(grace.Running()) == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, grace.Wait(), errWorker)
+
+	// every task has its own span which is ended once
+	count := make(map[string]int)
+	for _, span := range exporter.GetSpans() {
+		count[span.Name]++
+	}
+
+	require.Equal(t, map[string]int{ShutdownSpanName: 1, "worker": 2, "close/flush": 2}, count)
+}
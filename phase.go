@@ -0,0 +1,226 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HookFunc is a cleanup function registered on a Phase.
+type HookFunc func(ctx context.Context) error
+
+// PhaseOptions
+type PhaseOptions struct {
+	// Timeout is the share of Options.Timeout this phase is allowed to use.
+	// a zero-value indicates the phase gets an even share of what is left of
+	// Options.Timeout after the phases with an explicit Timeout are accounted for.
+	// When Options.Timeout is zero, Timeout is used as is and zero means no deadline.
+	Timeout time.Duration
+
+	// Critical phases are run even when an escalation step skips the phases that
+	// have not started yet. See EscalationStep.SkipNonCritical.
+	Critical bool
+}
+
+// A Phase is one step of the shutdown sequence, e.g. "stop accepting traffic" or
+// "close connections". Hooks of the same phase run concurrently, but a phase only
+// starts after every hook of the previous phase has returned.
+type Phase struct {
+	name  string
+	opts  PhaseOptions
+	hooks []hook
+	grace *Graceful
+
+	// started is set once the hooks of the phase have been collected to run.
+	started bool
+}
+
+type hook struct {
+	f HookFunc
+	t *task
+}
+
+// Name returns the name of the phase.
+func (p *Phase) Name() string {
+	return p.name
+}
+
+// Phase returns the phase with the given name. If it does not exist yet it is
+// appended to the shutdown sequence, so phases run in the order they are first
+// created. opts is ignored when the phase already exists.
+func (grace *Graceful) Phase(name string, opts PhaseOptions) *Phase {
+	grace.mu.Lock()
+	for _, p := range grace.phases {
+		if p.name == name {
+			grace.mu.Unlock()
+			return p
+		}
+	}
+
+	p := &Phase{name: name, opts: opts, grace: grace}
+	grace.phases = append(grace.phases, p)
+	first := len(grace.phases) == 1
+	grace.mu.Unlock()
+
+	// the phases are run by a single go-routine which is only
+	// started when the first phase is created.
+	if first {
+		grace.g.Go(grace.runPhases)
+	}
+
+	return p
+}
+
+// Hook registers f on the phase p. f is called once shutdown has reached p, with
+// the shutdown context limited to the sub-deadline of p. Hooks must be registered
+// before p starts running, later ones are logged and never called.
+func (p *Phase) Hook(f HookFunc) *Phase {
+	return p.HookNamed(funcName(f), f)
+}
+
+// HookNamed is like Hook but uses name as the name of the task of f
+// instead of the function name.
+func (p *Phase) HookNamed(name string, f HookFunc) *Phase {
+	name = p.name + "/" + name
+
+	// checking and appending at once, so the hook can not be appended
+	// right after runPhases started the phase and copied its hooks
+	p.grace.mu.Lock()
+	late := p.started || p.grace.phasesDone
+	if !late {
+		p.hooks = append(p.hooks, hook{f: f, t: p.grace.addTask(name)})
+	}
+	p.grace.mu.Unlock()
+
+	if late {
+		p.grace.logger.Warn("hook registered after its shutdown phase started, it is not run", "hook", name)
+	}
+
+	return p
+}
+
+// runPhases waits for the shutdown to begin and then runs every phase in order.
+// Errors of all phases are joined so a failing phase does not prevent the next
+// ones from running.
+func (grace *Graceful) runPhases() error {
+	<-grace.ctx.Done()
+	grace.startShutdown()
+
+	var errs []error
+
+	// phases are looked up for every step so phases created during shutdown still run
+	for i := 0; ; i++ {
+		grace.mu.Lock()
+		if i >= len(grace.phases) {
+			grace.phasesDone = true
+			grace.mu.Unlock()
+			break
+		}
+
+		phases := make([]*Phase, len(grace.phases)-i)
+		copy(phases, grace.phases[i:])
+		phases[0].started = true
+		grace.mu.Unlock()
+
+		p := phases[0]
+		if !p.opts.Critical && grace.skipNonCritical.Load() {
+			grace.logger.Warn("skipping shutdown phase", "phase", p.name)
+			continue
+		}
+
+		// the deadline is looked up for every phase since it can be extended or shortened
+		var (
+			deadline, _ = grace.shutdownCtx.Deadline()
+			start       = grace.clock.Now()
+			budget      = phaseBudget(phases, deadline, start)
+		)
+
+		grace.logger.Info("running shutdown phase", "phase", p.name, "budget", budget)
+
+		err := grace.runPhase(p, budget)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("phase '%s': %w", p.name, err))
+		}
+
+		grace.logger.Info("shutdown phase done", "phase", p.name, "elapsed", grace.clock.Now().Sub(start), "error", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runPhase runs all hooks of p concurrently and waits for them to return.
+// A zero budget indicates no deadline.
+func (grace *Graceful) runPhase(p *Phase, budget time.Duration) error {
+	ctx := grace.shutdownCtx
+	if budget != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, grace.clock, budget)
+		defer cancel()
+	}
+
+	grace.mu.Lock()
+	hooks := make([]hook, len(p.hooks))
+	copy(hooks, p.hooks)
+	grace.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(hooks))
+	)
+
+	for i, h := range hooks {
+		wg.Add(1)
+		go func(i int, h hook) {
+			defer wg.Done()
+			errs[i] = grace.runTask(h.t, func() error {
+				return h.f(ctx)
+			})
+		}(i, h)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// phaseBudget calculates the budget of phases[0] at now given the phases that
+// are still left to run. When deadline is zero the phase timeout is used as is.
+func phaseBudget(phases []*Phase, deadline, now time.Time) time.Duration {
+	p := phases[0]
+	if deadline.IsZero() {
+		return p.opts.Timeout
+	}
+
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		// a negative or zero budget would be treated as no deadline
+		// so use the smallest possible value to expire immediately.
+		return time.Nanosecond
+	}
+
+	if p.opts.Timeout != 0 {
+		return min(p.opts.Timeout, remaining)
+	}
+
+	var (
+		reserved time.Duration
+		shares   int
+	)
+
+	for _, next := range phases {
+		if next.opts.Timeout != 0 {
+			reserved += next.opts.Timeout
+			continue
+		}
+
+		shares++
+	}
+
+	if reserved >= remaining {
+		return time.Nanosecond
+	}
+
+	return (remaining - reserved) / time.Duration(shares)
+}
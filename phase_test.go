@@ -0,0 +1,234 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhases(t *testing.T) {
+	t.Run("phases run in order", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{})
+
+		var (
+			mu    sync.Mutex
+			order []string
+			hook  = func(name string, d time.Duration) HookFunc {
+				return func(ctx context.Context) error {
+					time.Sleep(d)
+					mu.Lock()
+					defer mu.Unlock()
+					order = append(order, name)
+					return nil
+				}
+			}
+		)
+
+		drain := grace.Phase("drain", PhaseOptions{})
+		closing := grace.Phase("close", PhaseOptions{})
+
+		closing.Hook(hook("close db", 0))
+		drain.Hook(hook("drain http", 100*time.Millisecond))
+		drain.Hook(hook("drain grpc", 50*time.Millisecond))
+
+		// getting an existing phase should not change the order
+		require.Equal(t, drain, grace.Phase("drain", PhaseOptions{}))
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		require.NoError(t, grace.Wait())
+		require.Equal(t, []string{"drain grpc", "drain http", "close db"}, order)
+	})
+
+	t.Run("hooks get a sub-deadline", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Timeout: time.Second,
+		})
+
+		grace.th.timeoutFunc = func() {}
+
+		var first, second, third time.Duration
+		grace.Phase("first", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			first = time.Until(deadline)
+			return nil
+		})
+
+		grace.Phase("second", PhaseOptions{Timeout: 100 * time.Millisecond}).Hook(func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			second = time.Until(deadline)
+			<-ctx.Done()
+			return nil
+		})
+
+		grace.Phase("third", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			third = time.Until(deadline)
+			return nil
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		require.NoError(t, grace.Wait())
+		require.InDelta(t, 450*time.Millisecond, first, float64(50*time.Millisecond))
+		require.InDelta(t, 100*time.Millisecond, second, float64(20*time.Millisecond))
+		require.InDelta(t, 900*time.Millisecond, third, float64(50*time.Millisecond))
+	})
+
+	t.Run("hooks are not born canceled", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{})
+
+		grace.Phase("close", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			require.False(t, ok)
+			return ctx.Err()
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		require.NoError(t, grace.Wait())
+	})
+
+	t.Run("errors do not stop next phases", func(t *testing.T) {
+		var (
+			grace = NewGracefulWithContext(context.Background(), Options{})
+			err1  = errors.New("flush failed")
+			err2  = errors.New("close failed")
+		)
+
+		grace.Phase("flush", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			return err1
+		})
+
+		grace.Phase("close", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			return err2
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		err := grace.Wait()
+		require.ErrorIs(t, err, err1)
+		require.ErrorIs(t, err, err2)
+	})
+
+	t.Run("registered during shutdown", func(t *testing.T) {
+		var (
+			grace = NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+			order []string
+		)
+
+		grace.Phase("flush", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			order = append(order, "flush")
+
+			// the phase has started, so this hook is never run
+			grace.Phase("flush", PhaseOptions{}).HookNamed("late", func(ctx context.Context) error {
+				order = append(order, "late")
+				return nil
+			})
+
+			// the phase has not started yet
+			grace.Phase("close", PhaseOptions{}).HookNamed("close", func(ctx context.Context) error {
+				order = append(order, "close")
+				return nil
+			})
+
+			return nil
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		require.NoError(t, grace.Wait())
+		require.Equal(t, []string{"flush", "close"}, order)
+		require.Len(t, grace.WaitReport().Tasks, 2)
+	})
+}
+
+func TestPhaseBudget(t *testing.T) {
+	phases := []*Phase{
+		{opts: PhaseOptions{}},
+		{opts: PhaseOptions{Timeout: 2 * time.Second}},
+		{opts: PhaseOptions{}},
+	}
+
+	now := time.Now()
+	require.Equal(t, time.Duration(0), phaseBudget(phases, time.Time{}, now))
+	require.Equal(t, 2*time.Second, phaseBudget(phases[1:], time.Time{}, now))
+
+	deadline := now.Add(10 * time.Second)
+	require.Equal(t, 4*time.Second, phaseBudget(phases, deadline, now))
+	require.Equal(t, 2*time.Second, phaseBudget(phases[1:], deadline, now))
+	require.Equal(t, 10*time.Second, phaseBudget(phases[2:], deadline, now))
+
+	// the budget is exhausted
+	require.Equal(t, time.Nanosecond, phaseBudget(phases, now.Add(time.Second), now))
+	require.Equal(t, time.Nanosecond, phaseBudget(phases, now.Add(-time.Second), now))
+}
+
+func TestPhaseSkipNonCritical(t *testing.T) {
+	var (
+		ran       []string
+		mu        sync.Mutex
+		escalated = make(chan struct{})
+		grace     = NewGracefulWithContext(context.Background(), Options{
+			Logger:     NopLogger(),
+			Timeout:    time.Hour,
+			Escalation: []EscalationStep{{SkipNonCritical: true, Timeout: time.Second}},
+		})
+	)
+
+	grace.th.timeoutFunc = func() {}
+
+	record := func(name string) HookFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	grace.Phase("drain", PhaseOptions{}).Hook(func(ctx context.Context) error {
+		// the second signal arrives while draining
+		<-escalated
+		return record("drain")(ctx)
+	})
+	grace.Phase("flush", PhaseOptions{}).Hook(record("flush"))
+	grace.Phase("close", PhaseOptions{Critical: true}).Hook(func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Second {
+			return errors.New("deadline was not shortened")
+		}
+
+		return record("close")(ctx)
+	})
+
+	grace.sh.sigChan <- syscall.SIGINT
+	require.Eventually(t, func() bool {
+		return len(grace.Running()) == 1
+	}, time.Second, time.Millisecond)
+
+	grace.sh.sigChan <- syscall.SIGINT
+	require.Eventually(t, grace.skipNonCritical.Load, time.Second, time.Millisecond)
+	close(escalated)
+
+	require.NoError(t, grace.Wait())
+	require.Equal(t, []string{"drain", "close"}, ran)
+}
@@ -0,0 +1,100 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// reloadSignal is the signal that reloads the registered reloaders
+// instead of starting graceful shutdown, once there are any.
+var reloadSignal os.Signal = syscall.SIGHUP
+
+// A Reloader reloads its configuration, certificates, etc. while the application keeps running.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+// ReloaderFunc is an adapter to allow the use of ordinary functions as Reloader.
+type ReloaderFunc func(ctx context.Context) error
+
+// Reload calls f(ctx).
+func (f ReloaderFunc) Reload(ctx context.Context) error {
+	return f(ctx)
+}
+
+type reloader struct {
+	name string
+	r    Reloader
+}
+
+// RegisterReloader registers r to be reloaded on SIGHUP, or when Reload is called. Once a
+// Reloader is registered SIGHUP does not start graceful shutdown anymore, unless it is
+// mapped to another action by Options.SignalActions. Components implementing Reloader
+// are registered by Register.
+func (grace *Graceful) RegisterReloader(name string, r Reloader) {
+	grace.mu.Lock()
+	grace.reloaders = append(grace.reloaders, reloader{name: name, r: r})
+	first := len(grace.reloaders) == 1
+	grace.mu.Unlock()
+
+	if _, ok := grace.opts.SignalActions[reloadSignal]; first && !ok {
+		grace.sh.setAction(reloadSignal, SignalAction{Kind: SignalReload})
+	}
+}
+
+// Reload reloads every registered Reloader in the order they were registered. Only one
+// reload runs at a time and each of them is limited by Options.ReloadTimeout. A failing
+// Reloader does not prevent the next ones from being reloaded, their errors are joined.
+func (grace *Graceful) Reload(ctx context.Context) error {
+	grace.reloadMu.Lock()
+	defer grace.reloadMu.Unlock()
+
+	grace.mu.Lock()
+	reloaders := make([]reloader, len(grace.reloaders))
+	copy(reloaders, grace.reloaders)
+	grace.mu.Unlock()
+
+	var (
+		start = grace.clock.Now()
+		errs  []error
+	)
+
+	for _, r := range reloaders {
+		grace.logger.Debug("reloading", "reloader", r.name)
+		if err := grace.reloadOne(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("reloader '%s': %w", r.name, err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		grace.logger.Error("reload failed", "elapsed", grace.clock.Now().Sub(start), "error", err)
+	} else {
+		grace.logger.Info("reloaded", "reloaders", len(reloaders), "elapsed", grace.clock.Now().Sub(start))
+	}
+
+	grace.observe(Event{Kind: EventReload, Duration: grace.clock.Now().Sub(start), Err: err})
+	return err
+}
+
+// reloadOne reloads r, limited by Options.ReloadTimeout.
+func (grace *Graceful) reloadOne(ctx context.Context, r reloader) error {
+	if grace.opts.ReloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, grace.clock, grace.opts.ReloadTimeout)
+		defer cancel()
+	}
+
+	return r.r.Reload(ctx)
+}
+
+// reload is the SignalHandlerOptions.ReloadFunc of Graceful. The reload runs on its
+// own go-routine so signals can still be handled while it is in progress.
+func (grace *Graceful) reload(sig os.Signal) {
+	go func() {
+		_ = grace.Reload(grace.ctx)
+	}()
+}
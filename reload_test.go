@@ -0,0 +1,181 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type reloadableComponent struct {
+	testComponent
+	reloads chan struct{}
+}
+
+func (c *reloadableComponent) Reload(ctx context.Context) error {
+	c.reloads <- struct{}{}
+	return nil
+}
+
+func TestReload(t *testing.T) {
+	t.Run("on SIGHUP", func(t *testing.T) {
+		var (
+			mu     sync.Mutex
+			events []string
+			o      = &testObserver{}
+			c      = &reloadableComponent{
+				testComponent: testComponent{name: "config", mu: &mu, events: &events},
+				reloads:       make(chan struct{}, 1),
+			}
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), Observers: []Observer{o}})
+		)
+
+		defer cancel()
+
+		grace.Register("config", c)
+		grace.sh.sigChan <- syscall.SIGHUP
+
+		select {
+		case <-c.reloads:
+		case <-time.After(time.Second):
+			t.Fatal("not reloaded")
+		}
+
+		// SIGHUP does not start graceful shutdown anymore
+		require.NoError(t, grace.ctx.Err())
+		require.Nil(t, grace.sh.Signal())
+
+		require.Eventually(t, func() bool {
+			return o.event(EventReload).Kind == EventReload
+		}, time.Second, time.Millisecond)
+		require.NoError(t, o.event(EventReload).Err)
+
+		grace.sh.sigChan <- syscall.SIGTERM
+		require.NoError(t, grace.Wait())
+	})
+
+	t.Run("without reloaders", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		grace.sh.sigChan <- syscall.SIGHUP
+		require.NoError(t, grace.Wait())
+		require.Equal(t, syscall.SIGHUP, grace.sh.Signal())
+	})
+
+	t.Run("mapped by signal actions", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger: NopLogger(),
+			SignalActions: map[os.Signal]SignalAction{
+				syscall.SIGHUP: {Kind: SignalShutdown},
+			},
+		})
+
+		grace.RegisterReloader("config", ReloaderFunc(func(ctx context.Context) error {
+			t.Error("reloaded")
+			return nil
+		}))
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		grace.sh.sigChan <- syscall.SIGHUP
+		require.NoError(t, grace.Wait())
+		require.Equal(t, syscall.SIGHUP, grace.sh.Signal())
+	})
+
+	t.Run("errors and timeout", func(t *testing.T) {
+		var (
+			errReload = errors.New("invalid config")
+			reloaded  []string
+			grace     = NewGracefulWithContext(context.Background(), Options{
+				Logger:        NopLogger(),
+				ReloadTimeout: 10 * time.Millisecond,
+			})
+		)
+
+		grace.RegisterReloader("config", ReloaderFunc(func(ctx context.Context) error {
+			reloaded = append(reloaded, "config")
+			return errReload
+		}))
+
+		grace.RegisterReloader("certs", ReloaderFunc(func(ctx context.Context) error {
+			reloaded = append(reloaded, "certs")
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+
+		err := grace.Reload(context.Background())
+		require.ErrorIs(t, err, errReload)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "reloader 'config': invalid config")
+		require.Equal(t, []string{"config", "certs"}, reloaded)
+	})
+
+	t.Run("timeout per reloader", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:        NopLogger(),
+			ReloadTimeout: 50 * time.Millisecond,
+		})
+
+		// together they take longer than ReloadTimeout
+		for _, name := range []string{"config", "certs"} {
+			grace.RegisterReloader(name, ReloaderFunc(func(ctx context.Context) error {
+				select {
+				case <-time.After(30 * time.Millisecond):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}))
+		}
+
+		require.NoError(t, grace.Reload(context.Background()))
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		var (
+			running, maxRunning int
+			mu                  sync.Mutex
+			wg                  sync.WaitGroup
+			grace               = NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		)
+
+		grace.RegisterReloader("slow", ReloaderFunc(func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		}))
+
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, grace.Reload(context.Background()))
+			}()
+		}
+
+		wg.Wait()
+		require.Equal(t, 1, maxRunning)
+	})
+}
@@ -0,0 +1,114 @@
+package gograce
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// Report describes how a Graceful run ended. It is returned by WaitReport.
+type Report struct {
+	// Signal is the signal that triggered shutdown.
+	// a nil value indicates shutdown was not triggered by a signal.
+	Signal os.Signal
+
+	// ParentCanceled reports whether shutdown was triggered by canceling the
+	// context passed to NewGracefulWithContext.
+	ParentCanceled bool
+
+	// TimedOut reports whether Options.Timeout was reached before all tasks returned.
+	TimedOut bool
+
+	// Tasks has an entry for every go-routine and hook registered on Graceful, in the
+	// order they were registered, except the ones dropped by Options.MaxFinishedTasks.
+	Tasks []TaskReport
+
+	// Err is the error returned by Wait. It is nil when TimedOut is true.
+	Err error
+}
+
+// TaskReport describes a single go-routine or hook registered on Graceful.
+type TaskReport struct {
+	// Name is the name of the task. Tasks registered with Go or GoWithContext are named
+	// after the function, hooks of a phase are prefixed with the phase name and
+	// component tasks are named "<component>.Start" and "<component>.Stop".
+	Name string
+
+	// Start is when the task started running. A zero-value indicates it never started.
+	Start time.Time
+
+	// End is when the task returned. A zero-value indicates it has not returned.
+	End time.Time
+
+	// Duration is End - Start, or how long it has been running when the report was made.
+	Duration time.Duration
+
+	// Err is the error returned by the task.
+	Err error
+
+	// TimedOut reports whether the task was still running when Options.Timeout was reached.
+	TimedOut bool
+}
+
+// WaitReport calls Wait and returns a Report of the run. Unlike Wait, it also returns
+// once Options.Timeout is reached, reporting the tasks that are still running.
+func (grace *Graceful) WaitReport() Report {
+	var (
+		errCh    = make(chan error, 1)
+		err      error
+		timedOut bool
+	)
+
+	go func() {
+		errCh <- grace.Wait()
+	}()
+
+	select {
+	case err = <-errCh:
+		if errors.Is(err, ErrTimeout) {
+			err, timedOut = nil, true
+		}
+	case <-grace.shutdownCtx.Done():
+		timedOut = true
+	}
+
+	return grace.report(err, timedOut)
+}
+
+// report creates a Report from the current state of grace.
+func (grace *Graceful) report(err error, timedOut bool) Report {
+	r := Report{
+		Signal:         grace.sh.Signal(),
+		ParentCanceled: grace.parent.Err() != nil,
+		TimedOut:       timedOut,
+		Err:            err,
+	}
+
+	now := grace.clock.Now()
+
+	grace.mu.Lock()
+	defer grace.mu.Unlock()
+
+	r.Tasks = make([]TaskReport, 0, len(grace.tasks))
+	for _, t := range grace.tasks {
+		tr := TaskReport{
+			Name:  t.name,
+			Start: t.start,
+			End:   t.end,
+			Err:   t.err,
+		}
+
+		switch {
+		case t.start.IsZero():
+		case t.end.IsZero():
+			tr.Duration = now.Sub(t.start)
+			tr.TimedOut = timedOut
+		default:
+			tr.Duration = t.end.Sub(t.start)
+		}
+
+		r.Tasks = append(r.Tasks, tr)
+	}
+
+	return r
+}
@@ -0,0 +1,143 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitReport(t *testing.T) {
+	t.Run("signal", func(t *testing.T) {
+		var (
+			grace     = NewGracefulWithContext(context.Background(), Options{})
+			errBackup = errors.New("backup failed")
+		)
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		grace.Phase("flush", PhaseOptions{}).Hook(func(ctx context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return errBackup
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGTERM
+		}()
+
+		r := grace.WaitReport()
+		require.Equal(t, syscall.SIGTERM, r.Signal)
+		require.False(t, r.ParentCanceled)
+		require.False(t, r.TimedOut)
+		require.ErrorIs(t, r.Err, errBackup)
+		require.Len(t, r.Tasks, 2)
+
+		require.Equal(t, "github.com/itzloop/gograce.TestWaitReport.func1.1", r.Tasks[0].Name)
+		require.NoError(t, r.Tasks[0].Err)
+		require.False(t, r.Tasks[0].End.IsZero())
+
+		require.Equal(t, "flush/github.com/itzloop/gograce.TestWaitReport.func1.2", r.Tasks[1].Name)
+		require.ErrorIs(t, r.Tasks[1].Err, errBackup)
+		require.GreaterOrEqual(t, r.Tasks[1].Duration, 50*time.Millisecond)
+		require.Equal(t, r.Tasks[1].End.Sub(r.Tasks[1].Start), r.Tasks[1].Duration)
+		require.False(t, r.Tasks[1].TimedOut)
+	})
+
+	t.Run("parent canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		grace := NewGracefulWithContext(ctx, Options{})
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		cancel()
+
+		r := grace.WaitReport()
+		require.Nil(t, r.Signal)
+		require.True(t, r.ParentCanceled)
+		require.NoError(t, r.Err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Timeout: 100 * time.Millisecond,
+		})
+
+		grace.th.timeoutFunc = func() {}
+
+		block := make(chan struct{})
+		defer close(block)
+
+		grace.OnShutdown(func(ctx context.Context) error {
+			return nil
+		})
+
+		grace.OnShutdown(func(ctx context.Context) error {
+			<-block
+			return nil
+		})
+
+		go func() {
+			grace.sh.sigChan <- syscall.SIGINT
+		}()
+
+		r := grace.WaitReport()
+		require.True(t, r.TimedOut)
+		require.NoError(t, r.Err)
+		require.Len(t, r.Tasks, 2)
+
+		require.False(t, r.Tasks[0].TimedOut)
+		require.True(t, r.Tasks[1].TimedOut)
+		require.True(t, r.Tasks[1].End.IsZero())
+		require.GreaterOrEqual(t, r.Tasks[1].Duration, 100*time.Millisecond)
+	})
+
+	t.Run("max finished tasks", func(t *testing.T) {
+		var (
+			errJob = errors.New("job failed")
+			grace  = NewGracefulWithContext(context.Background(), Options{
+				Logger:           NopLogger(),
+				MaxFinishedTasks: 2,
+			})
+		)
+
+		run := func(name string, err error) {
+			started := make(chan struct{})
+			grace.GoNamed(name, func(ctx context.Context) error {
+				close(started)
+				return err
+			})
+
+			// wait for the task to be done so they finish in order
+			<-started
+			require.Eventually(t, func() bool {
+				return grace.Running() == nil
+			}, time.Second, time.Millisecond)
+		}
+
+		run("failed", errJob)
+		for i := 0; i < 10; i++ {
+			run(strconv.Itoa(i), nil)
+		}
+
+		r := grace.WaitReport()
+		require.ErrorIs(t, r.Err, errJob)
+
+		var names []string
+		for _, tr := range r.Tasks {
+			names = append(names, tr.Name)
+		}
+
+		// failed tasks are never dropped
+		require.Equal(t, []string{"failed", "6", "7", "8", "9"}, names)
+	})
+}
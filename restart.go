@@ -0,0 +1,70 @@
+package gograce
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	// envListenFDs lists the network and address of the listeners passed to the
+	// new process on restart, in the order of their file descriptors.
+	envListenFDs = "GOGRACE_LISTEN_FDS"
+
+	// envReadyFD is the file descriptor the new process writes to once it is ready.
+	envReadyFD = "GOGRACE_READY_FD"
+
+	// listenFDsStart is the first file descriptor after stdin, stdout and stderr.
+	listenFDsStart = 3
+
+	defaultRestartTimeout = 30 * time.Second
+)
+
+type restartListener struct {
+	network string
+	addr    string
+	l       net.Listener
+}
+
+// Listen announces on the local network address like net.Listen. When the process
+// was started by a restart, the listener with the same network and address inherited
+// from the previous process is returned instead, so no connection is refused while
+// both processes are running. Listeners created by Listen are passed on to the new
+// process when Options.RestartSignal is received.
+func (grace *Graceful) Listen(network, addr string) (net.Listener, error) {
+	l, err := inheritedListener(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if l != nil {
+		grace.logger.Info("using inherited listener", "network", network, "addr", addr)
+	} else {
+		l, err = net.Listen(network, addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	grace.mu.Lock()
+	grace.listeners = append(grace.listeners, restartListener{network: network, addr: addr, l: l})
+	grace.mu.Unlock()
+
+	return l, nil
+}
+
+// restartCmd runs the same binary with the same arguments.
+func restartCmd() (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd, nil
+}
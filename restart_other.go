@@ -0,0 +1,19 @@
+//go:build !unix
+
+package gograce
+
+import (
+	"context"
+	"net"
+	"os"
+)
+
+func inheritedListener(network, addr string) (net.Listener, error) {
+	return nil, nil
+}
+
+func (grace *Graceful) notifyParent() {}
+
+func (grace *Graceful) watchRestart(ctx context.Context, sig os.Signal) {
+	grace.logger.Warn("restarts are not supported on this platform", "signal", sig.String())
+}
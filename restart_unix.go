@@ -0,0 +1,247 @@
+//go:build unix
+
+package gograce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	inherited   []inheritedFile
+
+	readyOnce sync.Once
+)
+
+type inheritedFile struct {
+	network string
+	addr    string
+	f       *os.File
+}
+
+// parseInherited reads the listeners passed by the previous process from the environment.
+// The variable is unset so it is not passed on to processes started by the application.
+func parseInherited() {
+	v := os.Getenv(envListenFDs)
+	if v == "" {
+		return
+	}
+
+	_ = os.Unsetenv(envListenFDs)
+
+	for i, entry := range strings.Split(v, ",") {
+		network, addr, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+
+		fd := uintptr(listenFDsStart + i)
+		inherited = append(inherited, inheritedFile{
+			network: network,
+			addr:    addr,
+			f:       os.NewFile(fd, entry),
+		})
+	}
+}
+
+// inheritedListener returns the listener with the given network and address passed by
+// the previous process or nil if there is none. Each listener is only returned once.
+func inheritedListener(network, addr string) (net.Listener, error) {
+	inheritOnce.Do(parseInherited)
+
+	inheritMu.Lock()
+	var f *os.File
+	for i, inh := range inherited {
+		if inh.network == network && inh.addr == addr {
+			f = inh.f
+			inherited = append(inherited[:i], inherited[i+1:]...)
+			break
+		}
+	}
+	inheritMu.Unlock()
+
+	if f == nil {
+		return nil, nil
+	}
+
+	// FileListener duplicates the file descriptor
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// notifyParent tells the previous process that this process is ready,
+// if it was started by a restart.
+func (grace *Graceful) notifyParent() {
+	readyOnce.Do(func() {
+		v := os.Getenv(envReadyFD)
+		if v == "" {
+			return
+		}
+
+		_ = os.Unsetenv(envReadyFD)
+
+		fd, err := strconv.Atoi(v)
+		if err != nil {
+			grace.logger.Error("invalid ready file descriptor", "fd", v, "error", err)
+			return
+		}
+
+		f := os.NewFile(uintptr(fd), "ready")
+		defer f.Close()
+
+		if _, err := f.Write([]byte{1}); err != nil {
+			grace.logger.Error("failed to notify the previous process", "error", err)
+		}
+	})
+}
+
+// watchRestart restarts the process whenever sig is received until ctx is done. Once a
+// restart succeeds graceful shutdown is started as if sig was received by the SignalHandler.
+func (grace *Graceful) watchRestart(ctx context.Context, sig os.Signal) {
+	// notify before returning so sig is never handled by the default action
+	sigChan := make(chan os.Signal, 1)
+	grace.opts.SignalSource.Notify(sigChan, sig)
+
+	go func() {
+		defer grace.opts.SignalSource.Stop(sigChan)
+
+		for {
+			select {
+			case <-sigChan:
+			case <-ctx.Done():
+				return
+			}
+
+			if err := grace.restart(); err != nil {
+				grace.logger.Error("restart failed", "error", err)
+				continue
+			}
+
+			grace.sh.trigger(sig)
+			return
+		}
+	}()
+}
+
+// restart starts a new process with the listeners created by Listen
+// and waits until it is ready.
+func (grace *Graceful) restart() error {
+	grace.mu.Lock()
+	listeners := make([]restartListener, len(grace.listeners))
+	copy(listeners, grace.listeners)
+	grace.mu.Unlock()
+
+	var (
+		files   = make([]*os.File, 0, len(listeners)+1)
+		entries = make([]string, 0, len(listeners))
+	)
+
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, rl := range listeners {
+		filer, ok := rl.l.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("listener %s:%s does not support passing its file descriptor", rl.network, rl.addr)
+		}
+
+		f, err := filer.File()
+		if err != nil {
+			return fmt.Errorf("listener %s:%s: %w", rl.network, rl.addr, err)
+		}
+
+		files = append(files, f)
+		entries = append(entries, rl.network+":"+rl.addr)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+	files = append(files, w)
+
+	cmd, err := grace.restartCmd()
+	if err != nil {
+		return err
+	}
+
+	cmd.ExtraFiles = files
+	cmd.Env = append(restartEnv(cmd.Env),
+		envListenFDs+"="+strings.Join(entries, ","),
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(entries)),
+	)
+
+	grace.logger.Info("restarting", "listeners", len(entries))
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// close the write end in this process so reading fails once the new process exits
+	w.Close()
+	files = files[:len(files)-1]
+
+	timeout := grace.opts.RestartTimeout
+	if timeout == 0 {
+		timeout = defaultRestartTimeout
+	}
+
+	readyCh := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		readyCh <- err
+	}()
+
+	timedOut := make(chan struct{})
+	timer := grace.clock.AfterFunc(timeout, func() { close(timedOut) })
+	defer timer.Stop()
+
+	select {
+	case err = <-readyCh:
+	case <-timedOut:
+		err = errors.New("timed out waiting for the new process to be ready")
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("new process %d: %w", cmd.Process.Pid, err)
+	}
+
+	grace.logger.Info("new process is ready", "pid", cmd.Process.Pid)
+
+	// the new process outlives this one
+	return cmd.Process.Release()
+}
+
+// restartEnv returns env, or the environment of this process if env is nil,
+// without the variables used for passing listeners.
+func restartEnv(env []string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envListenFDs+"=") || strings.HasPrefix(kv, envReadyFD+"=") {
+			continue
+		}
+
+		filtered = append(filtered, kv)
+	}
+
+	return filtered
+}
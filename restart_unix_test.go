@@ -0,0 +1,145 @@
+//go:build unix
+
+package gograce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const envRestartChild = "GOGRACE_TEST_RESTART_CHILD"
+
+// reply accepts connections from l and writes msg to them until l is closed.
+// It returns after the first connection if once is true.
+func reply(l net.Listener, msg string, once bool) error {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.Write([]byte(msg))
+		conn.Close()
+		if err != nil || once {
+			return err
+		}
+	}
+}
+
+func read(addr string) string {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return ""
+	}
+
+	defer conn.Close()
+
+	b, _ := io.ReadAll(conn)
+	return string(b)
+}
+
+// testRestartCmd runs the test binary with only the given test.
+func testRestartCmd(test string) func() (*exec.Cmd, error) {
+	return func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^"+test+"$")
+		cmd.Env = append(os.Environ(), envRestartChild+"=1")
+		return cmd, nil
+	}
+}
+
+func TestRestart(t *testing.T) {
+	t.Run("hand off listener", func(t *testing.T) {
+		grace := NewGracefulWithContext(context.Background(), Options{
+			Logger:        NopLogger(),
+			RestartSignal: syscall.SIGUSR2,
+		})
+		grace.restartCmd = testRestartCmd("TestRestartChild")
+
+		rawListener, err := grace.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		l := grace.Listener(rawListener, ListenerOptions{})
+		grace.GoWithContext(func(ctx context.Context) error {
+			return reply(l, "parent", false)
+		})
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- grace.Wait()
+		}()
+
+		require.Eventually(t, func() bool {
+			return read(l.Addr().String()) == "parent"
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+
+		// the parent shuts down once the child is ready
+		require.NoError(t, <-errCh)
+		require.Equal(t, syscall.SIGUSR2, grace.sh.Signal())
+
+		// the child serves on the same socket
+		require.Eventually(t, func() bool {
+			return read(l.Addr().String()) == "child"
+		}, 5*time.Second, time.Millisecond)
+	})
+
+	t.Run("child not ready", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		grace := NewGracefulWithContext(ctx, Options{
+			Logger:         NopLogger(),
+			RestartTimeout: time.Second,
+		})
+
+		// the child exits without becoming ready
+		grace.restartCmd = testRestartCmd("TestNothing")
+
+		_, err := grace.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		require.Error(t, grace.restart())
+		require.NoError(t, grace.ctx.Err())
+	})
+}
+
+// TestRestartChild is the new process started by TestRestart.
+func TestRestartChild(t *testing.T) {
+	if os.Getenv(envRestartChild) == "" {
+		t.Skip("only runs as the new process of TestRestart")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	grace := NewGracefulWithContext(ctx, Options{Logger: NopLogger()})
+
+	// the address is only the same because the listener is inherited
+	l, err := grace.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grace.GoWithContext(func(ctx context.Context) error {
+		go func() {
+			<-ctx.Done()
+			l.Close()
+		}()
+
+		return reply(l, "child", true)
+	})
+
+	require.NoError(t, grace.Wait())
+}
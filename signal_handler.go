@@ -2,32 +2,166 @@ package gograce
 
 import (
 	"context"
-	"log"
+	"errors"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"syscall"
-
-	"os/signal"
+	"time"
 )
 
 type ForceFunc func()
 
+// EscalationStep is what the SignalHandler does when another signal starting graceful
+// shutdown is received while graceful shutdown is in progress.
+type EscalationStep struct {
+	// SkipNonCritical skips the shutdown phases that have not started yet,
+	// unless they are critical. See PhaseOptions.Critical.
+	SkipNonCritical bool
+
+	// Timeout shortens what is left of the shutdown timeout to at most Timeout.
+	// a zero-value indicates the timeout is not changed.
+	Timeout time.Duration
+
+	// Force quits the application forcefully by calling ForceFunc.
+	Force bool
+}
+
+// SignalActionKind is what the SignalHandler does when a signal is received.
+type SignalActionKind int
+
+const (
+	// SignalShutdown starts graceful shutdown. When it is received while graceful
+	// shutdown is in progress the application is forcefully quit, if enabled.
+	SignalShutdown SignalActionKind = iota
+
+	// SignalDump writes the stack of all go-routines and forcefully quits the application.
+	SignalDump
+
+	// SignalReload calls SignalHandlerOptions.ReloadFunc. It is ignored while graceful
+	// shutdown is in progress.
+	SignalReload
+
+	// SignalCallback calls SignalAction.Func.
+	SignalCallback
+)
+
+// SignalAction maps a signal to what the SignalHandler does when it is received.
+type SignalAction struct {
+	Kind SignalActionKind
+
+	// Timeout replaces the shutdown timeout when graceful shutdown is started by
+	// a SignalShutdown action. Only used by Graceful when Options.Timeout is set,
+	// without it there is no timeout to replace and Timeout is ignored.
+	// a zero-value indicates the timeout is not replaced.
+	Timeout time.Duration
+
+	// Func is called with the received signal by a SignalCallback action.
+	// It is called from the go-routine handling signals so it should not block.
+	Func func(sig os.Signal)
+}
+
 var (
 	defaultSignals = [...]os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
 )
 
+// SignalError is the cause of the context returned by the SignalHandler being
+// canceled by a signal, see context.Cause.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return "received signal " + e.Signal.String()
+}
+
+// signalHandlerKey is the context key of the SignalHandler, so the signal can be
+// found in contexts that are derived without cancellation, e.g. the shutdown context.
+type signalHandlerKey struct{}
+
+// SignalFromContext returns the signal that started graceful shutdown of ctx or one of
+// its parents. ok is false when no signal has been received, e.g. when the parent context
+// was canceled or a go-routine returned an error.
+func SignalFromContext(ctx context.Context) (sig os.Signal, ok bool) {
+	var se *SignalError
+	if errors.As(context.Cause(ctx), &se) {
+		return se.Signal, true
+	}
+
+	if sh, _ := ctx.Value(signalHandlerKey{}).(*SignalHandler); sh != nil {
+		sig = sh.Signal()
+	}
+
+	return sig, sig != nil
+}
+
 // SignalHandlerOptions
 type SignalHandlerOptions struct {
 	// Force enables quiting forcefully (by sending one of the Signals twice)
-	// when graceful shutdown is in progress
+	// when graceful shutdown is in progress. Without it further signals are
+	// ignored, the SignalHandler keeps listening to them until the parent context
+	// is canceled or Close is called, so they do not terminate the process either.
 	Force bool
 
-	// Signals overwrites the defaultSignals.
+	// Escalation replaces what happens when Force is true and signals keep coming
+	// while graceful shutdown is in progress. The first step is taken on the second
+	// signal, the second step on the third signal and so on. Signals after the last
+	// step repeat it.
+	// a nil or empty value indicates the second signal quits forcefully.
+	Escalation []EscalationStep
+
+	// EscalateFunc is called with the received signal and the step for every
+	// escalation step that does not quit forcefully. It is called from the
+	// go-routine handling signals so it should not block.
+	// a nil value indicates such steps are ignored.
+	EscalateFunc func(sig os.Signal, step EscalationStep)
+
+	// MinSignalInterval is the minimum time between two signals for the second one
+	// to escalate, so an accidental double Ctrl+C does not quit forcefully. Signals
+	// received sooner after the previous one are ignored.
+	// a zero-value indicates no minimum.
+	MinSignalInterval time.Duration
+
+	// Signals overwrites the defaultSignals. Every signal starts graceful shutdown
+	// unless Actions maps it to another action.
 	Signals []os.Signal
 
+	// Actions maps signals to what the SignalHandler does when they are received.
+	// Signals that are not in Signals are listened to as well.
+	// a nil value indicates every signal in Signals starts graceful shutdown.
+	Actions map[os.Signal]SignalAction
+
+	// ReloadFunc is called with the received signal by SignalReload actions.
+	// It is called from the go-routine handling signals so it should not block.
+	// a nil value indicates SignalReload actions are ignored.
+	ReloadFunc func(sig os.Signal)
+
+	// StackDump is where SignalDump actions write the stack of all go-routines to.
+	// If StackDump is nil, os.Stderr will be used.
+	StackDump io.Writer
+
 	// ForceFunc is called when Force = true and one of the Signals is sent twice.
 	// If ForceFunc is nil, defaultForceFunc will be used which is os.Exit(1).
 	ForceFunc ForceFunc
+
+	// Logger is used to log received signals.
+	// If Logger is nil, NewSlogLogger(nil) will be used.
+	Logger Logger
+
+	// Observer is notified with EventSignal and EventForceQuit.
+	// a nil value indicates no observer.
+	Observer Observer
+
+	// SignalSource delivers the signals.
+	// If SignalSource is nil, the os/signal package will be used.
+	SignalSource SignalSource
+
+	// Clock is used to measure the time between signals.
+	// If Clock is nil, the time package will be used.
+	Clock Clock
 }
 
 // A SignalHandler listens for signals and handles graceful and forceful shutdown
@@ -35,12 +169,30 @@ type SignalHandlerOptions struct {
 // forceFunc is os.Exit(1) so application will terminate.
 type SignalHandler struct {
 	signals []os.Signal
+	actions map[os.Signal]SignalAction
 	force   bool
 	sigChan chan os.Signal
 
-	forceFunc ForceFunc
+	// triggerChan is used to start graceful shutdown as if a signal was received,
+	// it carries the cause the context is canceled with.
+	triggerChan chan error
+
+	escalation        []EscalationStep
+	escalateFunc      func(sig os.Signal, step EscalationStep)
+	minSignalInterval time.Duration
+
+	forceFunc  ForceFunc
+	reloadFunc func(sig os.Signal)
+	stackDump  io.Writer
+	logger     Logger
+	observer   Observer
+	source     SignalSource
+	clock      Clock
 
 	started atomic.Bool
+
+	mu       sync.Mutex
+	received os.Signal
 }
 
 // NewSignalHandler will create a signal handler based on the desired opts given.
@@ -54,11 +206,43 @@ func NewSignalHandler(ctx context.Context, opts SignalHandlerOptions) (*SignalHa
 		opts.ForceFunc = defaultForceFunc
 	}
 
+	if opts.Logger == nil {
+		opts.Logger = NewSlogLogger(nil)
+	}
+
+	if opts.StackDump == nil {
+		opts.StackDump = os.Stderr
+	}
+
+	if len(opts.Escalation) == 0 {
+		opts.Escalation = []EscalationStep{{Force: true}}
+	}
+
+	if opts.SignalSource == nil {
+		opts.SignalSource = osSignals{}
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
 	sh := &SignalHandler{
-		signals:   opts.Signals,
-		force:     opts.Force,
-		started:   atomic.Bool{},
-		forceFunc: opts.ForceFunc,
+		signals: opts.Signals,
+		actions: maps.Clone(opts.Actions),
+		force:   opts.Force,
+
+		escalation:        opts.Escalation,
+		escalateFunc:      opts.EscalateFunc,
+		minSignalInterval: opts.MinSignalInterval,
+
+		started:    atomic.Bool{},
+		forceFunc:  opts.ForceFunc,
+		reloadFunc: opts.ReloadFunc,
+		stackDump:  opts.StackDump,
+		logger:     opts.Logger,
+		observer:   opts.Observer,
+		source:     opts.SignalSource,
+		clock:      opts.Clock,
 	}
 
 	ctx = sh.Start(ctx)
@@ -70,6 +254,8 @@ func NewSignalHandler(ctx context.Context, opts SignalHandlerOptions) (*SignalHa
 // If at anypoint parent context gets canceled, Start will return. It is safe
 // but useless to call Start from multiple go-routines because it will start it
 // the first and you have to Close it first to be able to Start it again.
+// The returned context is canceled with a *SignalError as cause when a signal
+// is received, see SignalFromContext.
 func (s *SignalHandler) Start(ctx context.Context) context.Context {
 	if s.started.Swap(true) {
 		return ctx // TODO should this be nil or not?
@@ -79,65 +265,227 @@ func (s *SignalHandler) Start(ctx context.Context) context.Context {
 	// parent context gets canceled so we make a copy
 	// of it.
 	parentCtx := ctx
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := context.WithCancelCause(context.WithValue(ctx, signalHandlerKey{}, s))
 	s.sigChan = make(chan os.Signal, 1)
+	s.triggerChan = make(chan error, 1)
 
-	go func() {
-		signal.Notify(s.sigChan, s.signals...)
+	// notify before returning so signals sent right after are not missed
+	s.source.Notify(s.sigChan, s.notifySignals()...)
 
+	go func() {
 		defer s.Close()
 		var (
-			sig os.Signal
-			ok  bool
+			sig          os.Signal
+			ok           bool
+			received     time.Time
+			last         time.Time
+			shuttingDown bool
+			escalations  int
 		)
 
-		select {
-		case sig, ok = <-s.sigChan:
-			if !ok {
-				log.Println("signal channel closed quiting...")
-				return
-			}
-			log.Printf("received signal '%s', gracefully quitting...\n", sig)
-			cancel()
-		case <-parentCtx.Done():
-			log.Printf("parent context canceled\n")
-			return
-		}
+		for {
+			action := SignalAction{Kind: SignalShutdown}
 
-		if s.force {
 			select {
 			case sig, ok = <-s.sigChan:
 				if !ok {
-					log.Println("signal channel closed quiting...")
+					s.logger.Debug("signal channel closed, quitting")
 					return
 				}
 
-				log.Printf("received signal '%s', forcefully quitting...\n", sig)
-				cancel()
+				action = s.action(sig)
+			case cause := <-s.triggerChan:
+				if shuttingDown {
+					s.logger.Debug("ignoring shutdown, graceful shutdown in progress", "reason", cause)
+					continue
+				}
+
+				var se *SignalError
+				if errors.As(cause, &se) {
+					sig = se.Signal
+					break
+				}
+
+				shuttingDown = true
+				received = s.clock.Now()
+				last = received
+				s.logger.Info("shutdown requested, gracefully quitting", "reason", cause)
+				cancel(cause)
+				continue
 			case <-parentCtx.Done():
-				log.Printf("parent context canceled, while waiting for second signal\n")
+				if shuttingDown {
+					s.logger.Debug("parent context canceled while waiting for second signal")
+				} else {
+					s.logger.Debug("parent context canceled")
+				}
+
 				return
 			}
 
+			switch action.Kind {
+			case SignalDump:
+				s.logger.Warn("received signal, dumping stack and forcefully quitting", "signal", sig.String())
+				if err := writeStackDump(s.stackDump, false); err != nil {
+					s.logger.Error("failed to write stack dump", "error", err)
+				}
+
+				s.observe(Event{Kind: EventForceQuit, Time: s.clock.Now(), Signal: sig})
+				cancel(&SignalError{Signal: sig})
+				s.Close()
+
+				s.forceFunc()
+				return
+			case SignalReload:
+				if shuttingDown || s.reloadFunc == nil {
+					s.logger.Debug("ignoring reload signal", "signal", sig.String())
+					continue
+				}
+
+				s.logger.Info("received signal, reloading", "signal", sig.String())
+				s.reloadFunc(sig)
+				continue
+			case SignalCallback:
+				s.logger.Debug("received signal, calling callback", "signal", sig.String())
+				if action.Func != nil {
+					action.Func(sig)
+				}
+
+				continue
+			}
+
+			if !shuttingDown {
+				shuttingDown = true
+				received = s.clock.Now()
+				last = received
+				s.logger.Info("received signal, gracefully quitting", "signal", sig.String())
+				s.mu.Lock()
+				s.received = sig
+				s.mu.Unlock()
+				s.observe(Event{Kind: EventSignal, Time: received, Signal: sig})
+				cancel(&SignalError{Signal: sig})
+				continue
+			}
+
+			if !s.force {
+				s.logger.Debug("ignoring signal, graceful shutdown in progress", "signal", sig.String())
+				continue
+			}
+
+			if s.minSignalInterval > 0 && s.clock.Now().Sub(last) < s.minSignalInterval {
+				s.logger.Debug("ignoring signal, received too soon after the previous one", "signal", sig.String())
+				continue
+			}
+
+			last = s.clock.Now()
+			step := s.escalation[min(escalations, len(s.escalation)-1)]
+			escalations++
+
+			if !step.Force {
+				s.logger.Warn("received signal, escalating graceful shutdown", "signal", sig.String(),
+					"step", escalations, "skip_non_critical", step.SkipNonCritical, "timeout", step.Timeout)
+				if s.escalateFunc != nil {
+					s.escalateFunc(sig, step)
+				}
+
+				continue
+			}
+
+			s.logger.Warn("received signal, forcefully quitting", "signal", sig.String(), "elapsed", s.clock.Now().Sub(received))
+			s.observe(Event{Kind: EventForceQuit, Time: s.clock.Now(), Signal: sig})
+			cancel(&SignalError{Signal: sig})
 			s.Close()
 
 			s.forceFunc()
 			return
 		}
-
-		s.Close()
 	}()
 
 	return ctx
 }
 
+// notifySignals returns the signals to listen to, Signals followed by
+// the signals in Actions which are not in Signals.
+func (s *SignalHandler) notifySignals() []os.Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	signals := append([]os.Signal(nil), s.signals...)
+	for sig := range s.actions {
+		if !slices.Contains(signals, sig) {
+			signals = append(signals, sig)
+		}
+	}
+
+	return signals
+}
+
+// action returns the action for sig, which is SignalShutdown unless Actions says otherwise.
+func (s *SignalHandler) action(sig os.Signal) SignalAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if action, ok := s.actions[sig]; ok {
+		return action
+	}
+
+	return SignalAction{Kind: SignalShutdown}
+}
+
+// setAction maps sig to action after the SignalHandler has been created
+// and starts listening to sig if it is not listened to yet.
+func (s *SignalHandler) setAction(sig os.Signal, action SignalAction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.actions == nil {
+		s.actions = make(map[os.Signal]SignalAction)
+	}
+
+	s.actions[sig] = action
+	if s.sigChan != nil && !slices.Contains(s.signals, sig) {
+		s.source.Notify(s.sigChan, sig)
+	}
+}
+
+// Shutdown starts graceful shutdown as if a signal was received, except the context
+// is canceled with cause. Signals received afterwards quit forcefully, if enabled.
+// It has no effect when graceful shutdown has already started.
+func (s *SignalHandler) Shutdown(cause error) {
+	select {
+	case s.triggerChan <- cause:
+	default:
+	}
+}
+
+// trigger starts graceful shutdown as if sig was received.
+func (s *SignalHandler) trigger(sig os.Signal) {
+	s.Shutdown(&SignalError{Signal: sig})
+}
+
+// Signal returns the signal that started graceful shutdown or nil
+// if no signal has been received.
+func (s *SignalHandler) Signal() os.Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.received
+}
+
+func (s *SignalHandler) observe(e Event) {
+	if s.observer != nil {
+		s.observer.Observe(e)
+	}
+}
+
 // Close closes sigChan. Calls to close only work when SignalHandler has been started
 // and other wise it has no effect. It is also safe to call it from multiple go-routines.
 func (sh *SignalHandler) Close() {
-	if sh.started.Swap(false) {
+	if !sh.started.Swap(false) {
 		return
 	}
 
+	// make sure no signal is sent on the closed channel
+	sh.source.Stop(sh.sigChan)
 	close(sh.sigChan)
 }
 
@@ -1,7 +1,10 @@
 package gograce
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"os"
 	"sync"
 	"syscall"
 	"testing"
@@ -10,6 +13,17 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// stopSource is a SignalSource which closes stopped once Stop is called.
+type stopSource struct {
+	stopped chan struct{}
+}
+
+func (s *stopSource) Notify(c chan<- os.Signal, sig ...os.Signal) {}
+
+func (s *stopSource) Stop(c chan<- os.Signal) {
+	close(s.stopped)
+}
+
 func TestSignalHandler(t *testing.T) {
 	t.Run("without force", func(t *testing.T) {
 		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
@@ -27,12 +41,16 @@ func TestSignalHandler(t *testing.T) {
 	})
 
 	t.Run("with force", func(t *testing.T) {
-		var forceCalled bool
+		var (
+			forceCalled bool
+			forceCh     = make(chan struct{})
+		)
 		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
 			Force: true,
 		})
 
 		sh.forceFunc = func() {
+			defer close(forceCh)
 			forceCalled = true
 		}
 
@@ -43,6 +61,9 @@ func TestSignalHandler(t *testing.T) {
 
 		<-ctx.Done()
 		require.ErrorIs(t, ctx.Err(), context.Canceled)
+
+		// the context is canceled on the first signal, wait for the second one
+		<-forceCh
 		require.True(t, forceCalled)
 		require.False(t, sh.started.Load())
 	})
@@ -66,6 +87,20 @@ func TestSignalHandler(t *testing.T) {
 		require.False(t, sh.started.Load())
 	})
 
+	t.Run("cancel parent context stops signals", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		source := &stopSource{stopped: make(chan struct{})}
+		_, _ = NewSignalHandler(ctx, SignalHandlerOptions{SignalSource: source})
+
+		cancel()
+
+		select {
+		case <-source.stopped:
+		case <-time.After(time.Second):
+			t.Fatal("signals are still subscribed to after the parent context was canceled")
+		}
+	})
+
 	t.Run("multiple start and close", func(t *testing.T) {
 		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
 			Force: false,
@@ -157,3 +192,221 @@ func TestSignalHandler(t *testing.T) {
 		require.True(t, forceCalled)
 	})
 }
+
+func TestSignalHandlerActions(t *testing.T) {
+	t.Run("callback and reload", func(t *testing.T) {
+		var (
+			called   = make(chan os.Signal, 1)
+			reloaded = make(chan os.Signal, 1)
+		)
+
+		parent, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sh, ctx := NewSignalHandler(parent, SignalHandlerOptions{
+			Logger: NopLogger(),
+			Actions: map[os.Signal]SignalAction{
+				syscall.SIGUSR1: {Kind: SignalCallback, Func: func(sig os.Signal) { called <- sig }},
+				syscall.SIGHUP:  {Kind: SignalReload},
+			},
+			ReloadFunc: func(sig os.Signal) { reloaded <- sig },
+		})
+
+		sh.sigChan <- syscall.SIGUSR1
+		require.Equal(t, syscall.SIGUSR1, <-called)
+
+		sh.sigChan <- syscall.SIGHUP
+		require.Equal(t, syscall.SIGHUP, <-reloaded)
+
+		// neither of them starts graceful shutdown
+		require.NoError(t, ctx.Err())
+
+		sh.sigChan <- syscall.SIGTERM
+		<-ctx.Done()
+		require.Equal(t, syscall.SIGTERM, sh.Signal())
+
+		// reloads are ignored during graceful shutdown, callbacks are not
+		sh.sigChan <- syscall.SIGHUP
+		sh.sigChan <- syscall.SIGUSR1
+		require.Equal(t, syscall.SIGUSR1, <-called)
+		require.Empty(t, reloaded)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		var (
+			buf     bytes.Buffer
+			forceCh = make(chan struct{})
+		)
+
+		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
+			Logger:    NopLogger(),
+			StackDump: &buf,
+			Actions: map[os.Signal]SignalAction{
+				syscall.SIGQUIT: {Kind: SignalDump},
+			},
+			ForceFunc: func() { close(forceCh) },
+		})
+
+		// quits immediately, without graceful shutdown
+		sh.sigChan <- syscall.SIGQUIT
+		<-forceCh
+
+		require.ErrorIs(t, ctx.Err(), context.Canceled)
+		require.Nil(t, sh.Signal())
+		require.Contains(t, buf.String(), "goroutine ")
+	})
+
+	t.Run("notify signals", func(t *testing.T) {
+		sh := &SignalHandler{
+			signals: []os.Signal{syscall.SIGTERM, syscall.SIGHUP},
+			actions: map[os.Signal]SignalAction{
+				syscall.SIGHUP:  {Kind: SignalReload},
+				syscall.SIGQUIT: {Kind: SignalDump},
+			},
+		}
+
+		require.Equal(t, []os.Signal{syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}, sh.notifySignals())
+		require.Equal(t, SignalShutdown, sh.action(syscall.SIGTERM).Kind)
+		require.Equal(t, SignalReload, sh.action(syscall.SIGHUP).Kind)
+	})
+}
+
+func TestSignalHandlerEscalation(t *testing.T) {
+	t.Run("ladder", func(t *testing.T) {
+		var (
+			steps   = make(chan EscalationStep, 10)
+			forceCh = make(chan struct{})
+		)
+
+		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
+			Force:  true,
+			Logger: NopLogger(),
+			Escalation: []EscalationStep{
+				{SkipNonCritical: true, Timeout: time.Second},
+				{Force: true},
+			},
+			EscalateFunc: func(sig os.Signal, step EscalationStep) { steps <- step },
+			ForceFunc:    func() { close(forceCh) },
+		})
+
+		sh.sigChan <- syscall.SIGINT
+		<-ctx.Done()
+
+		sh.sigChan <- syscall.SIGINT
+		require.Equal(t, EscalationStep{SkipNonCritical: true, Timeout: time.Second}, <-steps)
+
+		sh.sigChan <- syscall.SIGTERM
+		<-forceCh
+		require.Empty(t, steps)
+	})
+
+	t.Run("last step repeats", func(t *testing.T) {
+		steps := make(chan EscalationStep, 10)
+
+		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
+			Force:        true,
+			Logger:       NopLogger(),
+			Escalation:   []EscalationStep{{Timeout: time.Second}},
+			EscalateFunc: func(sig os.Signal, step EscalationStep) { steps <- step },
+			ForceFunc:    func() { t.Error("forcefully quit") },
+		})
+
+		sh.sigChan <- syscall.SIGINT
+		<-ctx.Done()
+
+		for i := 0; i < 3; i++ {
+			sh.sigChan <- syscall.SIGINT
+			require.Equal(t, EscalationStep{Timeout: time.Second}, <-steps)
+		}
+	})
+
+	t.Run("empty escalation", func(t *testing.T) {
+		forceCh := make(chan struct{})
+
+		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
+			Force:      true,
+			Logger:     NopLogger(),
+			Escalation: []EscalationStep{},
+			ForceFunc:  func() { close(forceCh) },
+		})
+
+		sh.sigChan <- syscall.SIGINT
+		<-ctx.Done()
+
+		sh.sigChan <- syscall.SIGINT
+		<-forceCh
+	})
+
+	t.Run("min signal interval", func(t *testing.T) {
+		forceCh := make(chan time.Time, 1)
+
+		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{
+			Force:             true,
+			Logger:            NopLogger(),
+			MinSignalInterval: 100 * time.Millisecond,
+			ForceFunc:         func() { forceCh <- time.Now() },
+		})
+
+		start := time.Now()
+		sh.sigChan <- syscall.SIGINT
+		<-ctx.Done()
+
+		// an accidental double Ctrl+C is ignored
+		sh.sigChan <- syscall.SIGINT
+
+		select {
+		case <-forceCh:
+			t.Fatal("forcefully quit on a signal received too soon")
+		case <-time.After(150 * time.Millisecond):
+		}
+
+		sh.sigChan <- syscall.SIGINT
+		require.Greater(t, (<-forceCh).Sub(start), 100*time.Millisecond)
+	})
+}
+
+func TestSignalFromContext(t *testing.T) {
+	t.Run("signal", func(t *testing.T) {
+		sh, ctx := NewSignalHandler(context.Background(), SignalHandlerOptions{Logger: NopLogger()})
+
+		_, ok := SignalFromContext(ctx)
+		require.False(t, ok)
+
+		sh.sigChan <- syscall.SIGTERM
+		<-ctx.Done()
+
+		var se *SignalError
+		require.ErrorAs(t, context.Cause(ctx), &se)
+		require.Equal(t, syscall.SIGTERM, se.Signal)
+		require.EqualError(t, se, "received signal terminated")
+
+		sig, ok := SignalFromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, syscall.SIGTERM, sig)
+
+		// derived contexts without cancellation still know the signal
+		sig, ok = SignalFromContext(context.WithoutCancel(ctx))
+		require.True(t, ok)
+		require.Equal(t, syscall.SIGTERM, sig)
+	})
+
+	t.Run("parent canceled", func(t *testing.T) {
+		var (
+			errParent      = errors.New("parent")
+			parent, cancel = context.WithCancelCause(context.Background())
+			_, ctx         = NewSignalHandler(parent, SignalHandlerOptions{Logger: NopLogger()})
+		)
+
+		cancel(errParent)
+		<-ctx.Done()
+
+		require.ErrorIs(t, context.Cause(ctx), errParent)
+		_, ok := SignalFromContext(ctx)
+		require.False(t, ok)
+	})
+
+	t.Run("no signal handler", func(t *testing.T) {
+		_, ok := SignalFromContext(context.Background())
+		require.False(t, ok)
+	})
+}
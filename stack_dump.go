@@ -0,0 +1,48 @@
+package gograce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"strings"
+)
+
+// taskLabel is the pprof label set on go-routines running a task registered on Graceful.
+// Go-routines started by a task inherit it.
+const taskLabel = "gograce_task"
+
+// writeStackDump writes the stack of all go-routines to w. When tasksOnly is true,
+// only go-routines running a task registered on Graceful are written.
+func writeStackDump(w io.Writer, tasksOnly bool) error {
+	if !tasksOnly {
+		// same format as the one used when a go program panics
+		return pprof.Lookup("goroutine").WriteTo(w, 2)
+	}
+
+	// debug=1 groups go-routines with the same stack and labels, which
+	// is the only format that includes labels.
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return err
+	}
+
+	// the first line is the header, followed by records separated by an empty line
+	header, body, _ := strings.Cut(buf.String(), "\n")
+	if _, err := fmt.Fprintln(w, header); err != nil {
+		return err
+	}
+
+	label := fmt.Sprintf("%q:", taskLabel)
+	for _, record := range strings.Split(body, "\n\n") {
+		if !strings.Contains(record, "# labels: {") || !strings.Contains(record, label) {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(record)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
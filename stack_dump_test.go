@@ -0,0 +1,43 @@
+package gograce
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStackDump(t *testing.T) {
+	var (
+		ready = make(chan struct{})
+		block = make(chan struct{})
+	)
+
+	defer close(block)
+
+	go pprof.Do(context.Background(), pprof.Labels(taskLabel, "worker"), func(context.Context) {
+		close(ready)
+		<-block
+	})
+
+	<-ready
+
+	t.Run("all go-routines", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeStackDump(&buf, false))
+		require.Contains(t, buf.String(), "goroutine ")
+		require.Contains(t, buf.String(), "TestWriteStackDump.func1")
+		require.Contains(t, buf.String(), "TestWriteStackDump.func2")
+	})
+
+	t.Run("tasks only", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeStackDump(&buf, true))
+		require.Contains(t, buf.String(), "goroutine profile: total")
+		require.Contains(t, buf.String(), `# labels: {"gograce_task":"worker"}`)
+		require.Contains(t, buf.String(), "TestWriteStackDump.func1")
+		require.NotContains(t, buf.String(), "TestWriteStackDump.func2")
+	})
+}
@@ -0,0 +1,88 @@
+package gograce
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends state to the systemd notification socket, see sd_notify(3).
+// It has no effect when the application is not started by systemd.
+func (grace *Graceful) sdNotify(state string) {
+	if grace.notifySocket == "" {
+		return
+	}
+
+	addr := &net.UnixAddr{Name: grace.notifySocket, Net: "unixgram"}
+
+	// a leading @ indicates a socket in the abstract namespace
+	if addr.Name[0] == '@' {
+		addr.Name = "\x00" + addr.Name[1:]
+	}
+
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		grace.logger.Warn("failed to notify systemd", "state", state, "error", err)
+		return
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		grace.logger.Warn("failed to notify systemd", "state", state, "error", err)
+		return
+	}
+
+	grace.logger.Debug("notified systemd", "state", state)
+}
+
+// sdStopping tells systemd that shutdown has started and,
+// with Options.Timeout, how long it is going to take at most.
+func (grace *Graceful) sdStopping() {
+	state := "STOPPING=1"
+	if grace.th != nil {
+		state += "\nEXTEND_TIMEOUT_USEC=" + strconv.FormatInt(grace.th.Timeout().Microseconds(), 10)
+	}
+
+	grace.sdNotify(state)
+}
+
+// sdWatchdog sends keep-alive pings to the systemd watchdog at half the
+// interval systemd expects them, from the moment all components have started
+// until Wait returns. Components that never finish starting are caught by the
+// watchdog as well. It has no effect when the watchdog is not enabled for this
+// process.
+func (grace *Graceful) sdWatchdog() {
+	if grace.notifySocket == "" {
+		return
+	}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return
+	}
+
+	// the watchdog is meant for another process, e.g. the one before a restart
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return
+	}
+
+	interval := time.Duration(usec) * time.Microsecond / 2
+
+	// the timer is armed again after every ping until Wait returned
+	var ping func()
+	ping = func() {
+		switch grace.State() {
+		case StateStarting:
+		case StateStopped:
+			return
+		default:
+			grace.sdNotify("WATCHDOG=1")
+		}
+
+		grace.clock.AfterFunc(interval, ping)
+	}
+
+	grace.clock.AfterFunc(interval, ping)
+}
@@ -0,0 +1,192 @@
+package gograce
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// notifySocket listens on a unix datagram socket and sets NOTIFY_SOCKET to it.
+func notifySocket(t *testing.T) <-chan string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	states := make(chan string, 100)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+
+			states <- string(buf[:n])
+		}
+	}()
+
+	return states
+}
+
+// nextState returns the next state that is not a watchdog ping.
+func nextState(t *testing.T, states <-chan string) string {
+	t.Helper()
+
+	for {
+		select {
+		case state := <-states:
+			if state != "WATCHDOG=1" {
+				return state
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no state received")
+		}
+	}
+}
+
+func TestSystemd(t *testing.T) {
+	t.Run("ready and stopping", func(t *testing.T) {
+		var (
+			states      = notifySocket(t)
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), Timeout: 90 * time.Second, SystemdNotify: true})
+		)
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- grace.Wait()
+		}()
+
+		require.Equal(t, "READY=1\nMAINPID="+strconv.Itoa(os.Getpid()), nextState(t, states))
+
+		cancel()
+		require.NoError(t, <-errCh)
+		require.Equal(t, "STOPPING=1\nEXTEND_TIMEOUT_USEC=90000000", nextState(t, states))
+	})
+
+	t.Run("extend timeout", func(t *testing.T) {
+		var (
+			states      = notifySocket(t)
+			ctx, cancel = context.WithCancel(context.Background())
+			grace       = NewGracefulWithContext(ctx, Options{Logger: NopLogger(), Timeout: time.Hour, SystemdNotify: true})
+		)
+
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		cancel()
+		require.Equal(t, "STOPPING=1\nEXTEND_TIMEOUT_USEC=3600000000", nextState(t, states))
+
+		grace.ExtendTimeout(time.Hour)
+		state, ok := strings.CutPrefix(nextState(t, states), "EXTEND_TIMEOUT_USEC=")
+		require.True(t, ok)
+
+		usec, err := strconv.ParseInt(state, 10, 64)
+		require.NoError(t, err)
+		require.InDelta(t, (2 * time.Hour).Microseconds(), usec, float64(time.Second.Microseconds()))
+
+		require.NoError(t, grace.Wait())
+	})
+
+	t.Run("watchdog", func(t *testing.T) {
+		states := notifySocket(t)
+		t.Setenv("WATCHDOG_USEC", "20000")
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		grace := NewGracefulWithContext(ctx, Options{Logger: NopLogger(), SystemdNotify: true})
+		grace.GoWithContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		// no pings until the components have been started
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, states)
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- grace.Wait()
+		}()
+
+		require.Equal(t, "READY=1\nMAINPID="+strconv.Itoa(os.Getpid()), <-states)
+
+		for i := 0; i < 3; i++ {
+			select {
+			case state := <-states:
+				require.Equal(t, "WATCHDOG=1", state)
+			case <-time.After(time.Second):
+				t.Fatal("no watchdog ping received")
+			}
+		}
+
+		cancel()
+		require.NoError(t, <-errCh)
+		require.Equal(t, "STOPPING=1", nextState(t, states))
+
+		// pings stop once Wait returns
+		time.Sleep(20 * time.Millisecond)
+		for len(states) > 0 {
+			<-states
+		}
+
+		select {
+		case state := <-states:
+			t.Fatalf("received %q after Wait returned", state)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		states := notifySocket(t)
+		t.Setenv("WATCHDOG_USEC", "20000")
+
+		// SystemdNotify is not set
+		grace := NewGracefulWithContext(context.Background(), Options{Logger: NopLogger()})
+		require.NoError(t, grace.Wait())
+
+		select {
+		case state := <-states:
+			t.Fatalf("received %q without SystemdNotify", state)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("abstract socket", func(t *testing.T) {
+		name := "gograce-test-" + strconv.Itoa(os.Getpid())
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "\x00" + name, Net: "unixgram"})
+		if err != nil {
+			t.Skip("abstract sockets are not supported:", err)
+		}
+
+		defer conn.Close()
+
+		grace := &Graceful{notifySocket: "@" + name, logger: NopLogger()}
+		grace.sdNotify("READY=1")
+
+		buf := make([]byte, 64)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "READY=1", string(buf[:n]))
+	})
+}
@@ -0,0 +1,175 @@
+package gograce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// defaultMaxFinishedTasks is used when Options.MaxFinishedTasks is zero.
+const defaultMaxFinishedTasks = 1000
+
+// task keeps track of a single go-routine or hook, it is protected by Graceful.mu.
+type task struct {
+	name  string
+	start time.Time
+	end   time.Time
+	err   error
+}
+
+// TaskError is an error returned by a go-routine or hook, with the name of
+// the task it originates from.
+type TaskError struct {
+	Name string
+	Err  error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Running returns the names of the go-routines and hooks that are running,
+// in the order they were registered.
+func (grace *Graceful) Running() []string {
+	grace.mu.Lock()
+	defer grace.mu.Unlock()
+
+	var names []string
+	for _, t := range grace.tasks {
+		if !t.start.IsZero() && t.end.IsZero() {
+			names = append(names, t.name)
+		}
+	}
+
+	return names
+}
+
+// logRunning logs the tasks that are still running.
+func (grace *Graceful) logRunning() {
+	running := grace.Running()
+	if len(running) == 0 {
+		return
+	}
+
+	grace.logger.Warn("tasks still running", "tasks", running)
+}
+
+// collectErrors joins err, returned by the errgroup, with the errors returned by tasks in
+// the order they were registered as *TaskError. err is left out when it is the error of
+// a task or wraps one, e.g. when a phase or component failed, so it is not reported twice,
+// and kept otherwise. It returns nil if nothing has failed.
+func (grace *Graceful) collectErrors(err error) error {
+	grace.mu.Lock()
+	defer grace.mu.Unlock()
+
+	var errs []error
+	for _, t := range grace.tasks {
+		if t.err == nil {
+			continue
+		}
+
+		if errors.Is(t.err, err) || errors.Is(err, t.err) {
+			err = nil
+		}
+
+		errs = append(errs, &TaskError{Name: t.name, Err: t.err})
+	}
+
+	if err != nil {
+		errs = append([]error{err}, errs...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// newTask registers a task with the given name.
+func (grace *Graceful) newTask(name string) *task {
+	grace.mu.Lock()
+	defer grace.mu.Unlock()
+
+	return grace.addTask(name)
+}
+
+// addTask is like newTask for callers that need to register the task together
+// with other changes. grace.mu must be held.
+func (grace *Graceful) addTask(name string) *task {
+	t := &task{name: name}
+	grace.tasks = append(grace.tasks, t)
+	return t
+}
+
+// runTask calls f and updates t when f starts and returns.
+func (grace *Graceful) runTask(t *task, f func() error) error {
+	grace.mu.Lock()
+	t.start = grace.clock.Now()
+	grace.mu.Unlock()
+
+	// label the go-routine so it can be found in stack dumps
+	var err error
+	pprof.Do(context.Background(), pprof.Labels(taskLabel, t.name), func(context.Context) {
+		err = f()
+	})
+
+	grace.mu.Lock()
+	t.end = grace.clock.Now()
+	t.err = err
+	elapsed := t.end.Sub(t.start)
+	if err == nil {
+		grace.finished++
+		grace.pruneTasks()
+	}
+	grace.mu.Unlock()
+
+	grace.logger.Debug("task done", "task", t.name, "elapsed", elapsed, "error", err)
+	grace.observe(Event{Kind: EventTaskDone, Task: t.name, Duration: elapsed, Err: err})
+
+	return err
+}
+
+// pruneTasks drops the oldest tasks that returned without an error once there are
+// twice as many as Options.MaxFinishedTasks, so Go can be called for every request
+// or job without keeping all of them. grace.mu must be held.
+func (grace *Graceful) pruneTasks() {
+	limit := grace.opts.MaxFinishedTasks
+	if limit == 0 {
+		limit = defaultMaxFinishedTasks
+	}
+
+	if limit < 0 || grace.finished <= 2*limit {
+		return
+	}
+
+	drop := grace.finished - limit
+	kept := grace.tasks[:0]
+	for _, t := range grace.tasks {
+		if drop > 0 && !t.end.IsZero() && t.err == nil {
+			drop--
+			continue
+		}
+
+		kept = append(kept, t)
+	}
+
+	// clear the tail so dropped tasks can be garbage collected
+	clear(grace.tasks[len(kept):])
+	grace.tasks = kept
+	grace.finished = limit
+}
+
+// funcName returns the name of the function f, which is used to name tasks.
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	return fn.Name()
+}
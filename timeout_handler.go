@@ -2,8 +2,9 @@ package gograce
 
 import (
 	"context"
-	"log"
+	"io"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -12,20 +13,74 @@ type TimeoutFunc func()
 
 // TimeoutHandlerOptions
 type TimeoutHandlerOptions struct {
-	// Timeout is the value that is passed to time.AfterFunc.
+	// Timeout is the value that is passed to Clock.AfterFunc.
 	Timeout time.Duration
 
-	// TimeoutFunc is the function that is passed to time.AfterFunc.
+	// MaxTimeout limits how far Extend can move the deadline, measured from when
+	// shutdown began.
+	// a zero-value indicates no limit.
+	MaxTimeout time.Duration
+
+	// TimeoutFunc is the function that is passed to Clock.AfterFunc.
 	TimeoutFunc TimeoutFunc
+
+	// StackDump is where the stack of all go-routines is written to when the
+	// timeout is reached, before TimeoutFunc is called.
+	// a nil value indicates no stack dump.
+	StackDump io.Writer
+
+	// StackDumpFile is like StackDump but writes to the file at the given path,
+	// which is created when the timeout is reached.
+	// a zero-value indicates no stack dump.
+	StackDumpFile string
+
+	// StackDumpTasksOnly filters the stack dump to go-routines running a
+	// go-routine or hook registered on Graceful.
+	StackDumpTasksOnly bool
+
+	// Logger is used to log when the timeout is reached.
+	// If Logger is nil, NewSlogLogger(nil) will be used.
+	Logger Logger
+
+	// Observer is notified with EventTimeout.
+	// a nil value indicates no observer.
+	Observer Observer
+
+	// Clock is used to measure the timeout.
+	// If Clock is nil, the time package will be used.
+	Clock Clock
 }
 
 // TimeoutHandler will set a hard limit for graceful shutdown. If that limit
 // is reached the program will call timeoutFunc. defaultTimeoutFunc is os.Exit(1)
 // so this will terminate the application.
 type TimeoutHandler struct {
-	timeout time.Duration
+	timeout    time.Duration
+	maxTimeout time.Duration
 
 	timeoutFunc TimeoutFunc
+
+	stackDump          io.Writer
+	stackDumpFile      string
+	stackDumpTasksOnly bool
+
+	logger   Logger
+	observer Observer
+	clock    Clock
+
+	// parent is the context passed to NewTimeoutHandler, the timeout is armed
+	// once it is done.
+	parent context.Context
+
+	// shutdownCtx is returned by Context, done is closed when timeout is reached.
+	shutdownCtx context.Context
+	done        chan struct{}
+
+	armOnce  sync.Once
+	mu       sync.Mutex
+	armed    time.Time
+	deadline time.Time
+	timer    Timer
 }
 
 // NewTimeoutHandler
@@ -34,9 +89,33 @@ func NewTimeoutHandler(ctx context.Context, opts TimeoutHandlerOptions) *Timeout
 		opts.TimeoutFunc = defaultTimeoutFunc
 	}
 
+	if opts.Logger == nil {
+		opts.Logger = NewSlogLogger(nil)
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
 	th := &TimeoutHandler{
 		timeout:     opts.Timeout,
+		maxTimeout:  opts.MaxTimeout,
 		timeoutFunc: opts.TimeoutFunc,
+		parent:      ctx,
+		done:        make(chan struct{}),
+
+		stackDump:          opts.StackDump,
+		stackDumpFile:      opts.StackDumpFile,
+		stackDumpTasksOnly: opts.StackDumpTasksOnly,
+
+		logger:   opts.Logger,
+		observer: opts.Observer,
+		clock:    opts.Clock,
+	}
+
+	th.shutdownCtx = &shutdownContext{
+		Context: context.WithoutCancel(ctx),
+		th:      th,
 	}
 
 	go th.Start(ctx)
@@ -47,13 +126,212 @@ func NewTimeoutHandler(ctx context.Context, opts TimeoutHandlerOptions) *Timeout
 func (th *TimeoutHandler) Start(ctx context.Context) {
 	<-ctx.Done() // make sure we are in termination phase
 
-	// create a timer to be able to handle timeouts
-	time.AfterFunc(th.timeout, func() {
-		log.Println("timeoutHandler: cleanup phase timeout reached, forcefully quitting...")
-		th.timeoutFunc()
+	th.arm()
+}
+
+// arm sets the deadline and creates a timer to be able to handle timeouts.
+// It is safe to call arm multiple times, only the first call has an effect.
+func (th *TimeoutHandler) arm() {
+	th.armOnce.Do(func() {
+		th.mu.Lock()
+		defer th.mu.Unlock()
+
+		th.armed = th.clock.Now()
+		th.deadline = th.armed.Add(th.timeout)
+		th.timer = th.clock.AfterFunc(th.timeout, func() {
+			// the deadline might have been extended or shortened since
+			th.mu.Lock()
+			timeout := th.deadline.Sub(th.armed)
+			th.mu.Unlock()
+
+			th.logger.Error("cleanup phase timeout reached, forcefully quitting", "timeout", timeout)
+			close(th.done)
+			if th.observer != nil {
+				th.observer.Observe(Event{Kind: EventTimeout, Time: th.clock.Now(), Duration: timeout})
+			}
+			th.dumpStack()
+			th.timeoutFunc()
+		})
 	})
 }
 
+// Stop stops the timer so the timeout is not reached anymore, e.g. once shutdown has
+// completed. It reports whether the timeout was stopped before it was reached. The
+// timeout is not armed anymore once stopped, even if shutdown begins afterwards.
+func (th *TimeoutHandler) Stop() bool {
+	th.armOnce.Do(func() {})
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if th.timer == nil {
+		return true
+	}
+
+	return th.timer.Stop()
+}
+
+// Timeout returns the duration after which the timeout is reached once shutdown has begun.
+func (th *TimeoutHandler) Timeout() time.Duration {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	return th.timeout
+}
+
+// setTimeout replaces the timeout. It has no effect once the timeout is armed.
+func (th *TimeoutHandler) setTimeout(timeout time.Duration) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if th.deadline.IsZero() {
+		th.timeout = timeout
+	}
+}
+
+// dumpStack writes the stack of all go-routines to stackDump and stackDumpFile
+// if they are set. Errors are only logged since the application is about to quit.
+func (th *TimeoutHandler) dumpStack() {
+	if th.stackDump != nil {
+		if err := writeStackDump(th.stackDump, th.stackDumpTasksOnly); err != nil {
+			th.logger.Error("failed to write stack dump", "error", err)
+		}
+	}
+
+	if th.stackDumpFile == "" {
+		return
+	}
+
+	f, err := os.Create(th.stackDumpFile)
+	if err != nil {
+		th.logger.Error("failed to create stack dump file", "path", th.stackDumpFile, "error", err)
+		return
+	}
+	defer f.Close()
+
+	if err = writeStackDump(f, th.stackDumpTasksOnly); err != nil {
+		th.logger.Error("failed to write stack dump", "error", err)
+		return
+	}
+
+	th.logger.Info("stack dump written", "path", th.stackDumpFile)
+}
+
+// Shorten moves the deadline so that at most timeout is left, e.g. half of Remaining.
+// It has no effect when less is left already or the timeout has been reached. Before
+// shutdown begins, the timeout itself is shortened.
+func (th *TimeoutHandler) Shorten(timeout time.Duration) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if th.deadline.IsZero() {
+		th.timeout = min(th.timeout, timeout)
+		return
+	}
+
+	// Stop fails when the timeout has been reached already
+	if th.deadline.Sub(th.clock.Now()) <= timeout || !th.timer.Stop() {
+		return
+	}
+
+	th.deadline = th.clock.Now().Add(timeout)
+	th.timer.Reset(timeout)
+}
+
+// Extend moves the deadline d later, e.g. when a long migration needs more time, but
+// not beyond MaxTimeout after shutdown began. It has no effect once the timeout has been
+// reached. Before shutdown begins, the timeout itself is extended.
+func (th *TimeoutHandler) Extend(d time.Duration) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if th.deadline.IsZero() {
+		timeout := th.timeout + d
+		if th.maxTimeout > 0 {
+			timeout = min(timeout, th.maxTimeout)
+		}
+
+		th.timeout = max(th.timeout, timeout)
+		return
+	}
+
+	deadline := th.deadline.Add(d)
+	if limit := th.armed.Add(th.maxTimeout); th.maxTimeout > 0 && deadline.After(limit) {
+		deadline = limit
+	}
+
+	// Stop fails when the timeout has been reached already
+	if !deadline.After(th.deadline) || !th.timer.Stop() {
+		return
+	}
+
+	th.deadline = deadline
+	th.timer.Reset(deadline.Sub(th.clock.Now()))
+}
+
+// Remaining returns how much time is left until the timeout is reached. Before
+// shutdown begins, it is the whole timeout.
+func (th *TimeoutHandler) Remaining() time.Duration {
+	// the parent context might be done before Start had the chance to arm the timeout
+	if th.parent.Err() != nil {
+		th.arm()
+	}
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if th.deadline.IsZero() {
+		return th.timeout
+	}
+
+	return max(th.deadline.Sub(th.clock.Now()), 0)
+}
+
+// Context returns the shutdown context. Unlike the context passed to NewTimeoutHandler,
+// it is not canceled when shutdown begins, but only when the timeout is reached.
+// Once shutdown has begun, its deadline is the time at which the timeout is reached.
+func (th *TimeoutHandler) Context() context.Context {
+	return th.shutdownCtx
+}
+
+// Deadline returns the time at which the timeout is reached. ok is false
+// when shutdown has not begun yet.
+func (th *TimeoutHandler) Deadline() (deadline time.Time, ok bool) {
+	// the parent context might be done before Start had the chance to arm the timeout
+	if th.parent.Err() != nil {
+		th.arm()
+	}
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	return th.deadline, !th.deadline.IsZero()
+}
+
+// shutdownContext carries the values of the parent context but is only
+// done when the TimeoutHandler fires.
+type shutdownContext struct {
+	context.Context
+	th *TimeoutHandler
+}
+
+func (c *shutdownContext) Deadline() (time.Time, bool) {
+	return c.th.Deadline()
+}
+
+func (c *shutdownContext) Done() <-chan struct{} {
+	return c.th.done
+}
+
+func (c *shutdownContext) Err() error {
+	select {
+	case <-c.th.done:
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
 func defaultTimeoutFunc() {
 	os.Exit(1)
 }
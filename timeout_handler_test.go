@@ -1,7 +1,10 @@
 package gograce
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -33,3 +36,171 @@ func TestTimeoutHandler(t *testing.T) {
 
 	require.True(t, timeoutFuncCalled)
 }
+
+func TestTimeoutHandlerContext(t *testing.T) {
+	type key struct{}
+
+	var (
+		wg          = sync.WaitGroup{}
+		ctx, cancel = context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	)
+
+	wg.Add(1)
+	th := NewTimeoutHandler(ctx, TimeoutHandlerOptions{
+		Timeout: 100 * time.Millisecond,
+		TimeoutFunc: func() {
+			wg.Done()
+		},
+	})
+
+	shutdownCtx := th.Context()
+	require.Equal(t, "value", shutdownCtx.Value(key{}))
+
+	_, ok := shutdownCtx.Deadline()
+	require.False(t, ok)
+
+	cancel()
+
+	deadline, ok := shutdownCtx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(100*time.Millisecond), deadline, 10*time.Millisecond)
+	require.NoError(t, shutdownCtx.Err())
+
+	// children inherit the deadline and are canceled with the shutdown context
+	childCtx, childCancel := context.WithTimeout(shutdownCtx, time.Hour)
+	defer childCancel()
+
+	childDeadline, ok := childCtx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, deadline, childDeadline)
+
+	wg.Wait()
+
+	<-shutdownCtx.Done()
+	require.ErrorIs(t, shutdownCtx.Err(), context.DeadlineExceeded)
+
+	<-childCtx.Done()
+	require.ErrorIs(t, childCtx.Err(), context.DeadlineExceeded)
+}
+
+func TestTimeoutHandlerStackDump(t *testing.T) {
+	var (
+		wg          = sync.WaitGroup{}
+		buf         bytes.Buffer
+		path        = filepath.Join(t.TempDir(), "stack.dump")
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	wg.Add(1)
+	NewTimeoutHandler(ctx, TimeoutHandlerOptions{
+		Timeout:       10 * time.Millisecond,
+		StackDump:     &buf,
+		StackDumpFile: path,
+		TimeoutFunc: func() {
+			wg.Done()
+		},
+	})
+
+	cancel()
+	wg.Wait()
+
+	require.Contains(t, buf.String(), "TestTimeoutHandlerStackDump")
+
+	dump, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(dump), "TestTimeoutHandlerStackDump")
+}
+
+func TestTimeoutHandlerAdjust(t *testing.T) {
+	t.Run("before shutdown", func(t *testing.T) {
+		th := NewTimeoutHandler(context.Background(), TimeoutHandlerOptions{
+			Timeout:    time.Minute,
+			MaxTimeout: 2 * time.Minute,
+			Logger:     NopLogger(),
+		})
+
+		th.Extend(30 * time.Second)
+		require.Equal(t, 90*time.Second, th.Timeout())
+		require.Equal(t, 90*time.Second, th.Remaining())
+
+		th.Extend(time.Hour)
+		require.Equal(t, 2*time.Minute, th.Timeout())
+
+		th.Shorten(10 * time.Second)
+		require.Equal(t, 10*time.Second, th.Remaining())
+	})
+
+	t.Run("during shutdown", func(t *testing.T) {
+		var (
+			timedOut    = make(chan struct{})
+			ctx, cancel = context.WithCancel(context.Background())
+			th          = NewTimeoutHandler(ctx, TimeoutHandlerOptions{
+				Timeout:     time.Hour,
+				MaxTimeout:  90 * time.Minute,
+				TimeoutFunc: func() { close(timedOut) },
+				Logger:      NopLogger(),
+			})
+		)
+
+		cancel()
+
+		start, ok := th.Deadline()
+		require.True(t, ok)
+
+		th.Extend(10 * time.Minute)
+		deadline, _ := th.Context().Deadline()
+		require.Equal(t, start.Add(10*time.Minute), deadline)
+
+		// bounded by MaxTimeout
+		th.Extend(time.Hour)
+		deadline, _ = th.Context().Deadline()
+		require.Equal(t, start.Add(30*time.Minute), deadline)
+		require.InDelta(t, 90*time.Minute, th.Remaining(), float64(time.Second))
+
+		th.Shorten(th.Remaining() / 2)
+		require.InDelta(t, 45*time.Minute, th.Remaining(), float64(time.Second))
+
+		th.Shorten(10 * time.Millisecond)
+		<-timedOut
+		require.Zero(t, th.Remaining())
+
+		// the timeout has been reached already
+		th.Extend(time.Minute)
+		require.Zero(t, th.Remaining())
+	})
+}
+
+func TestTimeoutHandlerStop(t *testing.T) {
+	t.Run("during shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		th := NewTimeoutHandler(ctx, TimeoutHandlerOptions{
+			Timeout:     10 * time.Millisecond,
+			TimeoutFunc: func() { t.Error("timeout reached after Stop") },
+			Logger:      NopLogger(),
+		})
+
+		cancel()
+		_, ok := th.Deadline()
+		require.True(t, ok)
+
+		require.True(t, th.Stop())
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, th.Context().Err())
+	})
+
+	t.Run("before shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		th := NewTimeoutHandler(ctx, TimeoutHandlerOptions{
+			Timeout:     10 * time.Millisecond,
+			TimeoutFunc: func() { t.Error("timeout reached after Stop") },
+			Logger:      NopLogger(),
+		})
+
+		require.True(t, th.Stop())
+
+		// the timeout is not armed anymore
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, th.Context().Err())
+	})
+}